@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tiagokriok/Git-Fuzzy/internal/config"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the configuration and report scan problems",
+		Long: `Doctor runs a full scan of every search path and reports, per path,
+how many repositories and directories were found, how long it took,
+and anything that kept it from seeing the whole tree: missing paths,
+unreadable directories and timeouts.`,
+		Args: cobra.NoArgs,
+		RunE: runDoctor,
+	}
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no configuration found, run gitf --setup first")
+		}
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	ok := true
+
+	configPath, _ := config.ConfigPath()
+	fmt.Printf("Config: %s\n", configPath)
+
+	if _, err := exec.LookPath(cfg.Editor); err != nil {
+		fmt.Printf("  ✗ editor %q not found in PATH\n", cfg.Editor)
+		ok = false
+	} else {
+		fmt.Printf("  ✓ editor %q\n", cfg.Editor)
+	}
+
+	// A full scan, so cached directories don't hide problems
+	index, err := rescanRoots(cfg, scanner.Roots(cfg), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan repositories: %w", err)
+	}
+
+	fmt.Printf("\nSearch paths (timeout %s):\n", cfg.ScanTimeoutDuration())
+	for _, report := range index.Diagnostics.Roots {
+		mark := "✓"
+		if !report.Healthy() {
+			mark = "✗"
+			ok = false
+		}
+		fmt.Printf("  %s %s: %d repos, %d directories in %s\n",
+			mark, report.Path, report.Repos, report.Dirs, report.Duration.Round(time.Millisecond))
+
+		for _, problem := range report.Problems {
+			fmt.Printf("      %s: %s\n", problem.Kind, problem.Err)
+		}
+		total := 0
+		for _, n := range report.ProblemCounts {
+			total += n
+		}
+		if hidden := total - len(report.Problems); hidden > 0 {
+			fmt.Printf("      … and %d more\n", hidden)
+		}
+	}
+
+	fmt.Printf("\n%d repositories found\n", len(index.Repositories))
+	if !ok {
+		return fmt.Errorf("problems found")
+	}
+	return nil
+}
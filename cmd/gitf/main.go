@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -13,6 +16,7 @@ import (
 	"github.com/tiagokriok/Git-Fuzzy/internal/history"
 	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
 	"github.com/tiagokriok/Git-Fuzzy/internal/ui"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
 )
 
 // Version information (injected at build time via ldflags)
@@ -58,6 +62,11 @@ automatically in your preferred editor.`,
 		return nil
 	}
 
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newWatchCmd())
+	rootCmd.AddCommand(newPinCmd())
+	rootCmd.AddCommand(newStatsCmd())
+
 	// Custom version template for cleaner output
 	rootCmd.SetVersionTemplate(`{{.Version}}` + "\n")
 
@@ -83,21 +92,50 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	repos, err := scanner.Scan(cfg.SearchPaths)
+	index, err := scanner.LoadIndex()
 	if err != nil {
-		return fmt.Errorf("failed to scan repositories: %w", err)
+		index = &scanner.Index{}
 	}
 
-	recents, err := history.LoadRecent()
-	if err == nil {
-		repos = scanner.ReorderByRecent(repos, recents)
+	// Render instantly from the cached index, if there is one, while a
+	// rescan streams repositories into the list in the background
+	roots := scanner.Roots(cfg)
+
+	var repos []scanner.Repository
+	if index.Covers(roots) {
+		repos = orderByRecent(index.Repositories)
 	}
 
-	if len(repos) == 0 {
-		return fmt.Errorf("no repositories found in search paths")
+	// Once the rescan is done, keep the list live as repositories are
+	// cloned or deleted
+	watcher := scanner.NewWatcher(roots)
+
+	// History is pruned once the list is up, and finishes before gitf exits
+	var pruning sync.WaitGroup
+	defer pruning.Wait()
+
+	rescan := func(found func(scanner.Repository)) ([]scanner.Repository, *scanner.Diagnostics, error) {
+		fresh, err := rescanRoots(cfg, roots, index, found)
+		if err != nil {
+			return nil, nil, err
+		}
+		watcher.Start(fresh)
+		if fresh.Diagnostics.TimedOut() {
+			// Repositories the scan didn't reach aren't gone: keep listing
+			// them, and keep the complete index for the next launch
+			return orderByRecent(mergeRepositories(repos, fresh.Repositories)), fresh.Diagnostics, nil
+		}
+		fresh.Save()
+		pruning.Add(1)
+		go func() {
+			defer pruning.Done()
+			pruneHistory(fresh.Repositories)
+		}()
+		return orderByRecent(fresh.Repositories), fresh.Diagnostics, nil
 	}
 
-	selected, err := ui.Run(repos, cfg)
+	selected, err := ui.Run(repos, cfg, rescan, watcher.Events())
+	watcher.Close()
 	if err != nil {
 		return fmt.Errorf("failed to run UI: %w", err)
 	}
@@ -112,13 +150,80 @@ func runTUI(cmd *cobra.Command, args []string) error {
 
 	recent, err := history.LoadRecent()
 	if err == nil {
-		recent.Add(selected.Path)
+		recent.Add(selected.Path, history.ActionEditor)
 		recent.Save()
 	}
 
 	return nil
 }
 
+// rescanRoots rescans roots, giving up on whatever is left once the
+// configured scan timeout expires. found, if non-nil, sees every repository
+// as soon as it is discovered.
+func rescanRoots(cfg *config.Config, roots []scanner.Root, prev *scanner.Index, found func(scanner.Repository)) (*scanner.Index, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ScanTimeoutDuration())
+	defer cancel()
+
+	return scanner.Stream(ctx, roots, prev, found)
+}
+
+// orderByRecent returns a copy of repos with recently opened ones first
+func orderByRecent(repos []scanner.Repository) []scanner.Repository {
+	ordered := slices.Clone(repos)
+
+	recents, err := history.LoadRecent()
+	if err == nil {
+		ordered = scanner.ReorderByRecent(ordered, recents)
+	}
+	return ordered
+}
+
+// mergeRepositories returns found along with the repositories of cached it
+// doesn't have, e.g. those a timed out scan didn't reach
+func mergeRepositories(cached, found []scanner.Repository) []scanner.Repository {
+	seen := make(map[string]bool, len(found))
+	for _, repo := range found {
+		seen[repo.Path] = true
+	}
+
+	merged := slices.Clone(found)
+	for _, repo := range cached {
+		if !seen[repo.Path] {
+			merged = append(merged, repo)
+		}
+	}
+	return merged
+}
+
+// pruneHistory forgets repositories that were deleted and follows those that
+// moved, now that a scan has found where everything is. Remotes are read
+// from the repositories' configuration files, as pruning may have to look
+// at every repository found.
+func pruneHistory(repos []scanner.Repository) {
+	recent, err := history.LoadRecent()
+	if err != nil {
+		return
+	}
+
+	paths := make([]string, len(repos))
+	byPath := make(map[string]scanner.Repository, len(repos))
+	for i, repo := range repos {
+		paths[i] = repo.Path
+		byPath[repo.Path] = repo
+	}
+	remoteURL := func(path string) (string, error) {
+		if url := vcs.ReadRemoteURL(byPath[path]); url != "" {
+			return url, nil
+		}
+		return "", errNoRemote
+	}
+	if recent.Prune(paths, remoteURL) {
+		recent.Save()
+	}
+}
+
+var errNoRemote = errors.New("no remote")
+
 func handleSetup(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
@@ -128,7 +233,7 @@ func handleSetup(cmd *cobra.Command, args []string) error {
 	if cfg != nil {
 		fmt.Println("\nCurrent configuration:")
 		fmt.Printf("  Editor: %s\n", cfg.Editor)
-		fmt.Printf("  Search Paths: %s\n\n", strings.Join(cfg.SearchPaths, ", "))
+		fmt.Printf("  Search Paths: %s\n\n", strings.Join(cfg.Paths(), ", "))
 		fmt.Println("Press Enter to setup or Ctrl+C to cancel...")
 		fmt.Scanln()
 	}
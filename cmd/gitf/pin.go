@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tiagokriok/Git-Fuzzy/internal/history"
+)
+
+func newPinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pin",
+		Short: "Keep repositories at the top of the list",
+		Long: `Pinned repositories are listed first, whatever the search or how often
+they are opened. Pins can also be toggled in the list with Ctrl+P.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add [path...]",
+		Short: "Pin repositories, the current directory by default",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updatePins(args, func(recent *history.Recent, path string) error {
+				info, err := os.Stat(path)
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() {
+					return fmt.Errorf("%s is not a directory", path)
+				}
+				recent.Pin(path)
+				fmt.Printf("📌 %s\n", path)
+				return nil
+			})
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "rm [path...]",
+		Aliases: []string{"remove"},
+		Short:   "Unpin repositories, the current directory by default",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updatePins(args, func(recent *history.Recent, path string) error {
+				if !recent.IsPinned(path) {
+					return fmt.Errorf("%s is not pinned", path)
+				}
+				recent.Unpin(path)
+				fmt.Printf("unpinned %s\n", path)
+				return nil
+			})
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List pinned repositories",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recent, err := history.LoadRecent()
+			if err != nil {
+				return fmt.Errorf("failed to load history: %w", err)
+			}
+
+			for _, path := range recent.Pinned {
+				if _, err := os.Stat(path); err != nil {
+					fmt.Printf("%s (missing)\n", path)
+				} else {
+					fmt.Println(path)
+				}
+			}
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// updatePins applies update to the absolute path of every argument, or of the
+// current directory without arguments, and saves the pins
+func updatePins(args []string, update func(recent *history.Recent, path string) error) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	recent, err := history.LoadRecent()
+	if err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	for _, arg := range args {
+		path, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		if err := update(recent, path); err != nil {
+			return err
+		}
+	}
+
+	if err := recent.Save(); err != nil {
+		return fmt.Errorf("failed to save pins: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tiagokriok/Git-Fuzzy/internal/config"
+	"github.com/tiagokriok/Git-Fuzzy/internal/history"
+	"github.com/tiagokriok/Git-Fuzzy/internal/metadata"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
+)
+
+// maxBarWidth is the width of the longest bar in the opens chart
+const maxBarWidth = 40
+
+type statsOptions struct {
+	top     int
+	weekly  bool
+	periods int
+	days    int
+}
+
+func newStatsCmd() *cobra.Command {
+	var opts statsOptions
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show which repositories are used, and which aren't",
+		Long: `Stats reports the repositories opened most, how many opens there were
+per day or week and how they were made (editor, terminal, browser or
+file manager). It also lists the repositories found that were never
+opened, and those neither opened nor committed to for a number of days,
+which are candidates for archiving.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.validate(); err != nil {
+				return err
+			}
+			return runStats(opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.top, "top", 10, "number of most opened repositories to show")
+	cmd.Flags().BoolVar(&opts.weekly, "weekly", false, "count opens per week instead of per day")
+	cmd.Flags().IntVar(&opts.periods, "periods", 14, "number of days, or weeks, to count opens for")
+	cmd.Flags().IntVar(&opts.days, "untouched", 90, "list repositories neither opened nor committed to for this many days")
+	return cmd
+}
+
+// validate rejects flag values that make no sense, like a negative count
+func (o statsOptions) validate() error {
+	for _, flag := range []struct {
+		name  string
+		value int
+	}{
+		{"top", o.top},
+		{"periods", o.periods},
+		{"untouched", o.days},
+	} {
+		if flag.value < 0 {
+			return fmt.Errorf("invalid --%s %d: must not be negative", flag.name, flag.value)
+		}
+	}
+	return nil
+}
+
+func runStats(opts statsOptions) error {
+	cfg, err := config.Load()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no configuration found, run gitf --setup first")
+		}
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	recent, err := history.LoadRecent()
+	if err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	index, err := scanner.LoadIndex()
+	if err != nil {
+		index = &scanner.Index{}
+	}
+	index, err = rescanRoots(cfg, scanner.Roots(cfg), index, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan repositories: %w", err)
+	}
+	if !index.Diagnostics.TimedOut() {
+		index.Save()
+	}
+
+	now := time.Now()
+	printMostOpened(recent, opts.top)
+	printOpensPer(recent, opts, now)
+	printActions(recent)
+	printNeverOpened(recent, index.Repositories)
+	printUntouched(recent, index.Repositories, opts.days, now)
+	return nil
+}
+
+func printMostOpened(recent *history.Recent, top int) {
+	fmt.Println("Most opened:")
+	entries := recent.MostOpened(top)
+	if len(entries) == 0 {
+		fmt.Println("  nothing opened yet")
+	}
+	for _, e := range entries {
+		fmt.Printf("  %5d  %s (last %s)\n", e.Count, e.Path, metadata.FormatAge(time.Unix(e.LastOpened, 0)))
+	}
+}
+
+func printOpensPer(recent *history.Recent, opts statsOptions, now time.Time) {
+	interval, unit, layout := history.Day, "day", "Mon Jan 02"
+	if opts.weekly {
+		interval, unit, layout = history.Week, "week", "Week of Jan 02"
+	}
+
+	periods := recent.OpensPer(interval, opts.periods, now)
+	most := 1
+	for _, period := range periods {
+		most = max(most, period.Opens)
+	}
+
+	fmt.Printf("\nOpens per %s:\n", unit)
+	for _, period := range periods {
+		bar := strings.Repeat("█", period.Opens*maxBarWidth/most)
+		fmt.Printf("  %-14s %4d %s\n", period.Start.Format(layout), period.Opens, bar)
+	}
+}
+
+func printActions(recent *history.Recent) {
+	counts := recent.ActionCounts()
+	if len(counts) == 0 {
+		return
+	}
+
+	actions := []history.Action{history.ActionEditor, history.ActionTerminal, history.ActionBrowser, history.ActionFileManager}
+	var parts []string
+	for _, action := range actions {
+		if n := counts[action]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", strings.ReplaceAll(string(action), "_", " "), n))
+		}
+	}
+	fmt.Printf("\nOpened with: %s\n", strings.Join(parts, " · "))
+}
+
+func printNeverOpened(recent *history.Recent, repos []scanner.Repository) {
+	var never []scanner.Repository
+	for _, repo := range repos {
+		if _, ok := recent.Entry(repo.Path); !ok {
+			never = append(never, repo)
+		}
+	}
+
+	fmt.Printf("\nNever opened: %d of %d repositories\n", len(never), len(repos))
+	for _, repo := range never {
+		fmt.Printf("  %s (%s)\n", repo.Name, repo.Path)
+	}
+}
+
+// printUntouched lists the repositories neither opened nor committed to in
+// the last days days, the least recently used first
+func printUntouched(recent *history.Recent, repos []scanner.Repository, days int, now time.Time) {
+	cutoff := now.AddDate(0, 0, -days)
+
+	cache, err := metadata.LoadCache()
+	if err != nil {
+		cache = metadata.NewCache()
+	}
+
+	type untouched struct {
+		repo   scanner.Repository
+		opened time.Time
+		commit time.Time
+	}
+
+	var stale []untouched
+	for _, repo := range repos {
+		var opened time.Time
+		if e, ok := recent.Entry(repo.Path); ok {
+			opened = time.Unix(e.LastOpened, 0)
+		}
+		if opened.After(cutoff) {
+			continue
+		}
+
+		commit := lastCommit(cache, repo)
+		if commit.After(cutoff) {
+			continue
+		}
+		stale = append(stale, untouched{repo, opened, commit})
+	}
+
+	slices.SortFunc(stale, func(a, b untouched) int {
+		return latest(a.opened, a.commit).Compare(latest(b.opened, b.commit))
+	})
+
+	fmt.Printf("\nUntouched for %d days: %d repositories\n", days, len(stale))
+	for _, u := range stale {
+		fmt.Printf("  %s (%s): opened %s, committed %s\n", u.repo.Name, u.repo.Path, describeTime(u.opened), describeTime(u.commit))
+	}
+}
+
+// lastCommit returns when repo was last committed to, from the metadata
+// cache when it is up to date
+func lastCommit(cache *metadata.Cache, repo scanner.Repository) time.Time {
+	if data, fresh, ok := cache.Get(repo); ok && fresh {
+		return data.LastCommit
+	}
+	commit, _ := vcs.GetLastCommitTime(repo)
+	return commit
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func describeTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return metadata.FormatAge(t)
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestStatsOptions_RejectsNegativeValues(t *testing.T) {
+	valid := statsOptions{top: 10, periods: 14, days: 90}
+	if err := valid.validate(); err != nil {
+		t.Errorf("expected the defaults to be valid, got %v", err)
+	}
+	if err := (statsOptions{}).validate(); err != nil {
+		t.Errorf("expected zero values to be valid, got %v", err)
+	}
+
+	for _, opts := range []statsOptions{
+		{top: -1, periods: 14, days: 90},
+		{top: 10, periods: -1, days: 90},
+		{top: 10, periods: 14, days: -1},
+	} {
+		if err := opts.validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", opts)
+		}
+	}
+}
+
+func TestStatsCmd_RejectsNegativePeriods(t *testing.T) {
+	cmd := newStatsCmd()
+	cmd.SetArgs([]string{"--periods", "-1"})
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+
+	if err := cmd.Execute(); err == nil {
+		t.Errorf("expected --periods -1 to be rejected")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/tiagokriok/Git-Fuzzy/internal/config"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func newWatchCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Keep the repository index up to date in the background",
+		Long: `Watch scans every search path once, then watches them and updates the
+repository index as repositories are cloned or deleted, printing each
+change. Left running, e.g. as a user service, it keeps gitf's startup
+list accurate without waiting for a rescan.`,
+		Args: cobra.NoArgs,
+		RunE: runWatch,
+	}
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no configuration found, run gitf --setup first")
+		}
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	index, err := scanner.LoadIndex()
+	if err != nil {
+		index = &scanner.Index{}
+	}
+
+	roots := scanner.Roots(cfg)
+	index, err = rescanRoots(cfg, roots, index, nil)
+	if err != nil {
+		return fmt.Errorf("failed to scan repositories: %w", err)
+	}
+	// A timed out scan is missing repositories; the watcher saves once it
+	// has found them
+	if !index.Diagnostics.TimedOut() {
+		if err := index.Save(); err != nil {
+			return fmt.Errorf("failed to save index: %w", err)
+		}
+	}
+	if summary := index.Diagnostics.Summary(); summary != "" {
+		fmt.Fprintf(os.Stderr, "⚠ %s, run gitf doctor for details\n", summary)
+	}
+	fmt.Printf("Watching %d repositories\n", len(index.Repositories))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher := scanner.NewWatcher(roots)
+	watcher.Start(index)
+	defer watcher.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events():
+			mark := "+"
+			if event.Kind == scanner.EventRemoved {
+				mark = "-"
+			}
+			fmt.Printf("%s %s (%s)\n", mark, event.Repo.Name, event.Repo.Path)
+
+			if err := watcher.Index().Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to save index: %v\n", err)
+			}
+		}
+	}
+}
@@ -3,17 +3,125 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/tiagokriok/Git-Fuzzy/internal/platform"
+	"github.com/tiagokriok/Git-Fuzzy/internal/safefile"
 )
 
 type Config struct {
-	Editor      string   `json:"editor"`
-	SearchPaths []string `json:"search_paths"`
-	FileManager string   `json:"file_manager,omitempty"`
-	Terminal    string   `json:"terminal,omitempty"`
+	Editor      string       `json:"editor"`
+	SearchPaths []SearchPath `json:"search_paths"`
+	FileManager string       `json:"file_manager,omitempty"`
+	Terminal    string       `json:"terminal,omitempty"`
+	Nested      bool         `json:"nested,omitempty"`
+	Ignore      []string     `json:"ignore,omitempty"`       // extra directory globs to skip
+	Include     []string     `json:"include,omitempty"`      // directory globs to scan even if ignored by default
+	ScanTimeout string       `json:"scan_timeout,omitempty"` // e.g. "30s"; roots not finished by then are reported
+	SearchIn    []string     `json:"search_in,omitempty"`    // fields the search box matches, see SearchFields
+}
+
+// SearchFields are the parts of a repository the search box can match: its
+// name, its path relative to the search path and the owner/repo of its
+// remote. Name matches rank higher than the others.
+var SearchFields = []string{"name", "path", "remote"}
+
+// DefaultScanTimeout bounds a scan when the config doesn't set scan_timeout
+const DefaultScanTimeout = 30 * time.Second
+
+// SearchPath is a directory to scan for repositories. In config.json it is
+// either a plain path string or an object carrying per-path options.
+type SearchPath struct {
+	Path           string   `json:"path"`
+	MaxDepth       int      `json:"max_depth,omitempty"`       // levels below Path to descend, 0 for unlimited
+	FollowSymlinks bool     `json:"follow_symlinks,omitempty"` // descend into symlinked directories
+	Nested         *bool    `json:"nested,omitempty"`          // overrides Config.Nested for this path
+	Ignore         []string `json:"ignore,omitempty"`          // take precedence over Config.Ignore
+	Include        []string `json:"include,omitempty"`         // take precedence over Config.Include
+}
+
+// searchPathOptions mirrors SearchPath without its JSON methods
+type searchPathOptions SearchPath
+
+func (sp *SearchPath) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*sp = SearchPath{Path: path}
+		return nil
+	}
+
+	var opts searchPathOptions
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return fmt.Errorf("search path must be a string or an object: %w", err)
+	}
+	if opts.Path == "" {
+		return fmt.Errorf("search path object is missing \"path\"")
+	}
+	if opts.MaxDepth < 0 {
+		return fmt.Errorf("search path %q: max_depth cannot be negative", opts.Path)
+	}
+
+	*sp = SearchPath(opts)
+	return nil
+}
+
+// MarshalJSON writes search paths without options as plain strings, keeping
+// simple configs as readable as before per-path options existed.
+func (sp SearchPath) MarshalJSON() ([]byte, error) {
+	if !sp.hasOptions() {
+		return json.Marshal(sp.Path)
+	}
+	return json.Marshal(searchPathOptions(sp))
+}
+
+func (sp SearchPath) hasOptions() bool {
+	return sp.MaxDepth != 0 || sp.FollowSymlinks || sp.Nested != nil || len(sp.Ignore) > 0 || len(sp.Include) > 0
+}
+
+// Paths returns the directory of every configured search path
+func (c *Config) Paths() []string {
+	paths := make([]string, len(c.SearchPaths))
+	for i, sp := range c.SearchPaths {
+		paths[i] = sp.Path
+	}
+	return paths
+}
+
+// ScanTimeoutDuration returns how long a scan may take before it gives up
+func (c *Config) ScanTimeoutDuration() time.Duration {
+	if c.ScanTimeout == "" {
+		return DefaultScanTimeout
+	}
+	d, err := time.ParseDuration(c.ScanTimeout)
+	if err != nil || d <= 0 {
+		return DefaultScanTimeout
+	}
+	return d
+}
+
+// SearchInFields returns the fields the search box matches, all of
+// them unless search_in narrows them down
+func (c *Config) SearchInFields() []string {
+	if len(c.SearchIn) == 0 {
+		return SearchFields
+	}
+	return c.SearchIn
+}
+
+// NestedFor reports whether scanning should continue below repositories
+// found in sp
+func (c *Config) NestedFor(sp SearchPath) bool {
+	if sp.Nested != nil {
+		return *sp.Nested
+	}
+	return c.Nested
 }
 
 func DefaultConfig() (*Config, error) {
@@ -29,8 +137,8 @@ func defaultConfig() (*Config, error) {
 
 	return &Config{
 		Editor: "nvim",
-		SearchPaths: []string{
-			filepath.Join(homeDir, "dev"), filepath.Join(homeDir, "projects"), filepath.Join(homeDir, "repos"), filepath.Join(homeDir, "workspaces")},
+		SearchPaths: []SearchPath{
+			{Path: filepath.Join(homeDir, "dev")}, {Path: filepath.Join(homeDir, "projects")}, {Path: filepath.Join(homeDir, "repos")}, {Path: filepath.Join(homeDir, "workspaces")}},
 		FileManager: platform.DetectFileManager(),
 		Terminal:    platform.DetectTerminal(),
 	}, nil
@@ -67,9 +175,45 @@ func load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &config, nil
 }
 
+// validate checks every ignore and include glob and the scan timeout up
+// front, so a typo is reported instead of silently misbehaving during a scan
+func (c *Config) validate() error {
+	if c.ScanTimeout != "" {
+		d, err := time.ParseDuration(c.ScanTimeout)
+		if err != nil {
+			return fmt.Errorf("bad scan_timeout %q: %w", c.ScanTimeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("scan_timeout must be positive, got %q", c.ScanTimeout)
+		}
+	}
+
+	for _, field := range c.SearchIn {
+		if !slices.Contains(SearchFields, field) {
+			return fmt.Errorf("bad search_in field %q, expected one of %s", field, strings.Join(SearchFields, ", "))
+		}
+	}
+
+	patterns := slices.Concat(c.Ignore, c.Include)
+	for _, sp := range c.SearchPaths {
+		patterns = slices.Concat(patterns, sp.Ignore, sp.Include)
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad glob pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
 func (c *Config) Save() error {
 	configPath, err := ConfigPath()
 	if err != nil {
@@ -79,23 +223,54 @@ func (c *Config) Save() error {
 	return save(configPath, c)
 }
 
+// save writes cfg to configPath under a lock. Settings in the file that this
+// version doesn't know, e.g. written by a newer gitf, are kept; a file that
+// isn't valid JSON is backed up before it is replaced.
 func save(configPath string, cfg *Config) error {
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
-	}
+	return safefile.Update(configPath, 0644, func(current []byte) ([]byte, error) {
+		unknown := make(map[string]json.RawMessage)
+		if current != nil {
+			if err := json.Unmarshal(current, &unknown); err != nil {
+				if _, err := safefile.Backup(configPath); err != nil {
+					return nil, err
+				}
+				clear(unknown)
+			}
+		}
+		for _, key := range configKeys() {
+			delete(unknown, key)
+		}
 
-	err = os.MkdirAll(filepath.Dir(configPath), 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
-	}
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+		if len(unknown) == 0 {
+			return data, nil
+		}
 
-	err = os.WriteFile(configPath, data, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
-	}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+		maps.Copy(unknown, fields)
 
-	return nil
+		data, err = json.MarshalIndent(unknown, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+		return data, nil
+	})
+}
+
+// configKeys lists the JSON keys of every Config field, set or not
+func configKeys() []string {
+	t := reflect.TypeFor[Config]()
+	keys := make([]string, t.NumField())
+	for i := range t.NumField() {
+		keys[i], _, _ = strings.Cut(t.Field(i).Tag.Get("json"), ",")
+	}
+	return keys
 }
 
 // GetFileManager returns configured file manager or auto-detects if empty
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func assertNoError(t *testing.T, err error) {
@@ -44,7 +45,7 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("expected %d search paths, got %d", expectedSearchPaths, len(cfg.SearchPaths))
 	}
 
-	for _, path := range cfg.SearchPaths {
+	for _, path := range cfg.Paths() {
 		if !strings.Contains(path, expectedHomeDir) {
 			t.Errorf("expected search path %q to contain %q", path, expectedHomeDir)
 		}
@@ -91,8 +92,8 @@ func TestLoad_FileNotExists(t *testing.T) {
 func TestSave_CreatesDirectory(t *testing.T) {
 	cfg := &Config{
 		Editor: "vim",
-		SearchPaths: []string{
-			"/test-dir",
+		SearchPaths: []SearchPath{
+			{Path: "/test-dir"},
 		},
 	}
 
@@ -124,10 +125,10 @@ func TestSave_CreatesDirectory(t *testing.T) {
 func TestLoadSave_RoundTrip(t *testing.T) {
 	originalCfg := &Config{
 		Editor: "code",
-		SearchPaths: []string{
-			"/home/user/dev",
-			"/home/user/projects",
-			"/home/user/workspace",
+		SearchPaths: []SearchPath{
+			{Path: "/home/user/dev"},
+			{Path: "/home/user/projects"},
+			{Path: "/home/user/workspace"},
 		},
 	}
 	tmpDir := t.TempDir()
@@ -147,9 +148,9 @@ func TestLoadSave_RoundTrip(t *testing.T) {
 		t.Fatalf("search paths mismatch: expected %d, got %d", len(originalCfg.SearchPaths), len(loadedCfg.SearchPaths))
 	}
 
-	for i, path := range originalCfg.SearchPaths {
-		if loadedCfg.SearchPaths[i] != path {
-			t.Errorf("search path mismatch at index %d: expected %q, got %q", i, path, loadedCfg.SearchPaths[i])
+	for i, path := range originalCfg.Paths() {
+		if loadedCfg.SearchPaths[i].Path != path {
+			t.Errorf("search path mismatch at index %d: expected %q, got %q", i, path, loadedCfg.SearchPaths[i].Path)
 		}
 	}
 
@@ -177,3 +178,174 @@ func TestLoad_InvalidJSON(t *testing.T) {
 		t.Errorf("expected error message to contain 'unmarshal', got: %v", err)
 	}
 }
+
+func TestLoad_MixedSearchPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	data := []byte(`{
+  "editor": "nvim",
+  "nested": true,
+  "search_paths": [
+    "/home/user/dev",
+    {"path": "/home/user/dotfiles", "nested": false},
+    {"path": "/home/user", "max_depth": 2, "follow_symlinks": true}
+  ]
+}`)
+	err := os.WriteFile(configFile, data, 0644)
+	assertNoError(t, err)
+
+	cfg, err := load(configFile)
+	assertNoError(t, err)
+
+	if len(cfg.SearchPaths) != 3 {
+		t.Fatalf("expected 3 search paths, got %d", len(cfg.SearchPaths))
+	}
+
+	assertEqual(t, "/home/user/dev", cfg.SearchPaths[0].Path, "plain path")
+	assertEqual(t, true, cfg.NestedFor(cfg.SearchPaths[0]), "inherited nested")
+	assertEqual(t, "/home/user/dotfiles", cfg.SearchPaths[1].Path, "object path")
+	assertEqual(t, false, cfg.NestedFor(cfg.SearchPaths[1]), "overridden nested")
+	assertEqual(t, 0, cfg.SearchPaths[0].MaxDepth, "default max_depth")
+	assertEqual(t, 2, cfg.SearchPaths[2].MaxDepth, "max_depth")
+	assertEqual(t, true, cfg.SearchPaths[2].FollowSymlinks, "follow_symlinks")
+}
+
+func TestSave_WritesPlainSearchPathsAsStrings(t *testing.T) {
+	nested := true
+	cfg := &Config{
+		Editor: "nvim",
+		SearchPaths: []SearchPath{
+			{Path: "/home/user/dev"},
+			{Path: "/home/user/mono", Nested: &nested},
+		},
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	err := save(configFile, cfg)
+	assertNoError(t, err)
+
+	data, err := os.ReadFile(configFile)
+	assertNoError(t, err)
+
+	if !strings.Contains(string(data), `"/home/user/dev"`) || strings.Contains(string(data), `{
+      "path": "/home/user/dev"`) {
+		t.Errorf("expected plain search path to be saved as a string, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), `"nested": true`) {
+		t.Errorf("expected per-path options to be saved, got:\n%s", data)
+	}
+}
+
+func TestLoad_InvalidSearchPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	err := os.WriteFile(configFile, []byte(`{"editor": "vim", "search_paths": [{"nested": true}]}`), 0644)
+	assertNoError(t, err)
+
+	if _, err := load(configFile); err == nil {
+		t.Fatal("expected error for search path without a path, got nil")
+	}
+}
+
+func TestLoad_InvalidIgnorePattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	err := os.WriteFile(configFile, []byte(`{"editor": "vim", "search_paths": [{"path": "/dev", "ignore": ["[build"]}]}`), 0644)
+	assertNoError(t, err)
+
+	_, err = load(configFile)
+	if err == nil {
+		t.Fatal("expected error for malformed glob pattern, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "[build") {
+		t.Errorf("expected error to mention the bad pattern, got: %v", err)
+	}
+}
+
+func TestLoad_NegativeMaxDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	err := os.WriteFile(configFile, []byte(`{"editor": "vim", "search_paths": [{"path": "/dev", "max_depth": -1}]}`), 0644)
+	assertNoError(t, err)
+
+	if _, err := load(configFile); err == nil {
+		t.Fatal("expected error for negative max_depth, got nil")
+	}
+}
+
+func TestLoad_ScanTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	err := os.WriteFile(configFile, []byte(`{"editor": "vim", "search_paths": ["/dev"], "scan_timeout": "5s"}`), 0644)
+	assertNoError(t, err)
+
+	cfg, err := load(configFile)
+	assertNoError(t, err)
+
+	if got := cfg.ScanTimeoutDuration(); got != 5*time.Second {
+		t.Errorf("expected scan timeout 5s, got %v", got)
+	}
+
+	err = os.WriteFile(configFile, []byte(`{"editor": "vim", "search_paths": ["/dev"], "scan_timeout": "soon"}`), 0644)
+	assertNoError(t, err)
+
+	if _, err := load(configFile); err == nil {
+		t.Fatal("expected error for malformed scan_timeout, got nil")
+	}
+}
+
+func TestSave_KeepsUnknownSettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	err := os.WriteFile(configFile, []byte(`{"editor": "vim", "search_paths": ["/dev"], "ignore": ["docs"], "theme": "dark"}`), 0644)
+	assertNoError(t, err)
+
+	err = save(configFile, &Config{Editor: "code", SearchPaths: []SearchPath{{Path: "/src"}}})
+	assertNoError(t, err)
+
+	data, err := os.ReadFile(configFile)
+	assertNoError(t, err)
+
+	if !strings.Contains(string(data), `"theme": "dark"`) {
+		t.Errorf("expected unknown setting to be kept, got %s", data)
+	}
+
+	cfg, err := load(configFile)
+	assertNoError(t, err)
+	assertEqual(t, "code", cfg.Editor, "editor")
+
+	// Cleared settings stay cleared rather than being merged back in
+	if len(cfg.Ignore) != 0 {
+		t.Errorf("expected ignore to be cleared, got %v", cfg.Ignore)
+	}
+}
+
+func TestSave_BacksUpCorruptFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+
+	err := os.WriteFile(configFile, []byte(`{"editor": "vim", "search_`), 0644)
+	assertNoError(t, err)
+
+	err = save(configFile, &Config{Editor: "code", SearchPaths: []SearchPath{{Path: "/src"}}})
+	assertNoError(t, err)
+
+	backups, err := filepath.Glob(configFile + ".corrupt-*")
+	assertNoError(t, err)
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, got %v", backups)
+	}
+
+	cfg, err := load(configFile)
+	assertNoError(t, err)
+	assertEqual(t, "code", cfg.Editor, "editor")
+}
@@ -7,11 +7,13 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // StatusData contains detailed git status information
 type StatusData struct {
 	CurrentBranch    string
+	Detached         bool // HEAD is not on a branch; CurrentBranch is "HEAD" then
 	TrackingBranch   string
 	AheadCount       int
 	BehindCount      int
@@ -73,6 +75,63 @@ func GetRemoteURL(repoPath string) (string, error) {
 	return url, nil
 }
 
+// GetHeadBranch returns the branch HEAD points at. Unlike GetDetailedStatus
+// it also works in bare repositories.
+func GetHeadBranch(repoPath string) (string, error) {
+	cmd := exec.Command("git", "symbolic-ref", "--short", "HEAD")
+	cmd.Dir = repoPath
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("HEAD is not on a branch")
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetLastCommitTime returns when the commit HEAD points at was made
+func GetLastCommitTime(repoPath string) (time.Time, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%ct", "HEAD")
+	cmd.Dir = repoPath
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		return time.Time{}, fmt.Errorf("no commits yet")
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(out.String()), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected commit time %q", out.String())
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// AddWorktree creates a linked worktree of repoPath at worktreePath with branch
+// checked out. The branch is created from HEAD if it doesn't exist yet.
+func AddWorktree(repoPath, worktreePath, branch string) error {
+	args := []string{"worktree", "add", worktreePath, branch}
+
+	verify := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	verify.Dir = repoPath
+	if err := verify.Run(); err != nil {
+		args = []string{"worktree", "add", "-b", branch, worktreePath}
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git worktree add failed: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
 // ConvertToHTTPS converts SSH git URLs to HTTPS URLs
 // Supports formats:
 // - git@github.com:user/repo.git
@@ -149,6 +208,7 @@ func getCurrentBranchInfo(repoPath string, data *StatusData) error {
 		return fmt.Errorf("failed to get current branch: %w", err)
 	}
 	data.CurrentBranch = strings.TrimSpace(out.String())
+	data.Detached = data.CurrentBranch == "HEAD"
 
 	// Get tracking branch
 	cmd = exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}")
@@ -210,7 +270,9 @@ func getStashCount(repoPath string, data *StatusData) error {
 
 // parseFileStatus parses git status output and categorizes files
 func parseFileStatus(repoPath string, data *StatusData) error {
-	cmd := exec.Command("git", "status", "--short")
+	// Without optional locks git doesn't refresh the index, which would bump
+	// its mtime and look like a change to anyone watching it
+	cmd := exec.Command("git", "--no-optional-locks", "status", "--short")
 	cmd.Dir = repoPath
 	var out bytes.Buffer
 	cmd.Stdout = &out
@@ -0,0 +1,158 @@
+package history
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Prune checks every entry and pin against the repositories found by the
+// latest scan and against the filesystem. Entries of repositories that are
+// gone are remapped to a found repository with the same remote URL, i.e. one
+// that was moved, or dropped otherwise. Entries of found repositories
+// remember their remote URL, so they can be followed once they move. Paths
+// that still exist are kept even if the scan didn't find them, as it may have
+// timed out or the search paths may have changed. Prune reports whether
+// anything changed.
+func (r *Recent) Prune(found []string, remoteURL func(path string) (string, error)) bool {
+	isFound := make(map[string]bool, len(found))
+	for _, path := range found {
+		isFound[path] = true
+	}
+	remotes := &remoteIndex{found: found, remoteURL: remoteURL, urls: make(map[string]string)}
+
+	changed := false
+	for _, e := range slices.Clone(r.Entries) {
+		switch {
+		case isFound[e.Path]:
+			if e.Remote != "" {
+				continue
+			}
+			if url, err := remoteURL(e.Path); err == nil {
+				r.apply(func(r *Recent) { r.setRemote(e.Path, url) })
+				changed = true
+			}
+		case exists(e.Path):
+			continue
+		default:
+			if moved, ok := remotes.find(e.Remote, filepath.Base(e.Path)); ok {
+				r.apply(func(r *Recent) { r.move(e.Path, moved) })
+			} else {
+				r.apply(func(r *Recent) { r.drop(e.Path) })
+			}
+			changed = true
+		}
+	}
+
+	// Pins of repositories that moved were moved with their entries above
+	for _, path := range slices.Clone(r.Pinned) {
+		if !isFound[path] && !exists(path) {
+			r.apply(func(r *Recent) { r.unpin(path) })
+			changed = true
+		}
+	}
+	return changed
+}
+
+// exists reports whether path may still exist; only a path known to be
+// missing counts as gone
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// remoteIndex looks up found repositories by remote URL, asking for each
+// repository's URL at most once and only when needed
+type remoteIndex struct {
+	found     []string
+	remoteURL func(path string) (string, error)
+	urls      map[string]string // by path
+}
+
+// find returns a found repository whose remote is url, preferring one named
+// name, since moved repositories usually keep their name while worktrees or
+// other clones of the same remote usually don't
+func (idx *remoteIndex) find(url, name string) (string, bool) {
+	if url == "" {
+		return "", false
+	}
+
+	var other string
+	for _, path := range idx.found {
+		sameName := filepath.Base(path) == name
+		if !sameName && other != "" {
+			continue
+		}
+		if idx.url(path) != url {
+			continue
+		}
+		if sameName {
+			return path, true
+		}
+		other = path
+	}
+	return other, other != ""
+}
+
+func (idx *remoteIndex) url(path string) string {
+	url, ok := idx.urls[path]
+	if !ok {
+		url, _ = idx.remoteURL(path)
+		idx.urls[path] = url
+	}
+	return url
+}
+
+func (r *Recent) index(repoPath string) int {
+	return slices.IndexFunc(r.Entries, func(e Entry) bool { return e.Path == repoPath })
+}
+
+func (r *Recent) setRemote(repoPath, url string) {
+	if i := r.index(repoPath); i >= 0 {
+		r.Entries[i].Remote = url
+	}
+}
+
+func (r *Recent) drop(repoPath string) {
+	r.Entries = slices.DeleteFunc(r.Entries, func(e Entry) bool { return e.Path == repoPath })
+}
+
+// move moves the entry and pin of a repository that moved to its new path,
+// merging the entry with that of the new path if that was opened already
+func (r *Recent) move(from, to string) {
+	if i := slices.Index(r.Pinned, from); i >= 0 {
+		if r.IsPinned(to) {
+			r.unpin(from)
+		} else {
+			r.Pinned[i] = to
+		}
+	}
+
+	i := r.index(from)
+	if i < 0 {
+		return
+	}
+	e := r.Entries[i]
+	r.drop(from)
+
+	j := r.index(to)
+	if j < 0 {
+		e.Path = to
+		r.Entries = append(r.Entries, e)
+		return
+	}
+	merged := &r.Entries[j]
+	merged.Rank += e.Rank
+	merged.Count += e.Count
+	merged.LastOpened = max(merged.LastOpened, e.LastOpened)
+	if merged.Remote == "" {
+		merged.Remote = e.Remote
+	}
+
+	merged.Opens = slices.Concat(e.Opens, merged.Opens)
+	slices.SortStableFunc(merged.Opens, func(a, b Open) int { return cmp.Compare(a.At, b.At) })
+	merged.Opens = trimOpens(merged.Opens)
+}
@@ -0,0 +1,138 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// remotes fakes git.GetRemoteURL
+func remotes(urls map[string]string) func(string) (string, error) {
+	return func(path string) (string, error) {
+		if url, ok := urls[path]; ok {
+			return url, nil
+		}
+		return "", errors.New("no remote configured")
+	}
+}
+
+func TestPrune_DropsDeletedRepositories(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "outside-search-paths")
+	if err := os.Mkdir(kept, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", kept, err)
+	}
+	deleted := filepath.Join(dir, "deleted")
+
+	now := time.Now()
+	r := &Recent{}
+	r.add(kept, ActionEditor, now)
+	r.add(deleted, ActionEditor, now)
+
+	if !r.Prune(nil, remotes(nil)) {
+		t.Fatal("expected Prune to report a change")
+	}
+	if len(r.Entries) != 1 || r.Entries[0].Path != kept {
+		t.Errorf("expected only %s to be kept, got %+v", kept, r.Entries)
+	}
+}
+
+func TestPrune_FollowsMovedRepositories(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old", "api")
+	newPath := filepath.Join(dir, "new", "api")
+	worktree := filepath.Join(dir, "new", "api-feature")
+	url := "git@github.com:acme/api.git"
+
+	now := time.Now()
+	r := &Recent{}
+	r.add(oldPath, ActionEditor, now)
+	r.add(oldPath, ActionEditor, now)
+
+	// The first scan records the remote while the repository is in place
+	found := remotes(map[string]string{oldPath: url})
+	if !r.Prune([]string{oldPath}, found) {
+		t.Fatal("expected Prune to record the remote")
+	}
+	if r.Entries[0].Remote != url {
+		t.Fatalf("expected remote %q, got %q", url, r.Entries[0].Remote)
+	}
+
+	// Then it moves; a worktree of the same remote must not win over it
+	found = remotes(map[string]string{worktree: url, newPath: url})
+	if !r.Prune([]string{worktree, newPath}, found) {
+		t.Fatal("expected Prune to report a change")
+	}
+	if len(r.Entries) != 1 || r.Entries[0].Path != newPath || r.Entries[0].Rank != 2 {
+		t.Errorf("expected the entry to move to %s with rank 2, got %+v", newPath, r.Entries)
+	}
+}
+
+func TestPrune_MergesIntoExistingEntry(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old", "api")
+	newPath := filepath.Join(dir, "new", "api")
+	url := "git@github.com:acme/api.git"
+
+	now := time.Now()
+	r := &Recent{Entries: []Entry{
+		{Path: oldPath, Rank: 3, LastOpened: now.Add(-time.Hour).Unix(), Remote: url},
+		{Path: newPath, Rank: 1, LastOpened: now.Unix()},
+	}}
+
+	r.Prune([]string{newPath}, remotes(map[string]string{newPath: url}))
+
+	if len(r.Entries) != 1 || r.Entries[0].Rank != 4 || r.Entries[0].LastOpened != now.Unix() {
+		t.Errorf("expected a single merged entry, got %+v", r.Entries)
+	}
+}
+
+func TestPrune_SavesChanges(t *testing.T) {
+	recentPath := filepath.Join(t.TempDir(), "recent.json")
+	deleted := filepath.Join(t.TempDir(), "deleted")
+	now := time.Now()
+
+	r := &Recent{}
+	r.add(deleted, ActionEditor, now)
+	if err := saveRecent(recentPath, r); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+
+	loaded, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+	loaded.Prune(nil, remotes(nil))
+	if err := saveRecent(recentPath, loaded); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+
+	loaded, err = loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+	if len(loaded.Entries) != 0 {
+		t.Errorf("expected the deleted repository to be gone, got %+v", loaded.Entries)
+	}
+}
+
+func TestPrune_MovesAndDropsPins(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old", "api")
+	newPath := filepath.Join(dir, "new", "api")
+	deleted := filepath.Join(dir, "deleted")
+	url := "git@github.com:acme/api.git"
+
+	r := &Recent{
+		Entries: []Entry{{Path: oldPath, Rank: 1, LastOpened: time.Now().Unix(), Remote: url}},
+		Pinned:  []string{oldPath, deleted},
+	}
+
+	r.Prune([]string{newPath}, remotes(map[string]string{newPath: url}))
+
+	if len(r.Pinned) != 1 || r.Pinned[0] != newPath {
+		t.Errorf("expected only %s to stay pinned, got %v", newPath, r.Pinned)
+	}
+}
@@ -1,15 +1,80 @@
 package history
 
 import (
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/safefile"
+)
+
+// MaxRank caps the sum of all ranks. Once it is exceeded every rank is aged,
+// i.e. scaled down, and entries whose rank drops below 1 are forgotten by the
+// ranking, the way zoxide and z keep it responsive to new habits.
+const MaxRank = 1000
+
+// agingFactor is how much of its rank every entry keeps when aging
+const agingFactor = 0.9
+
+// statsRetention is how long entries forgotten by the ranking are kept after
+// their last open, so their opens still count in gitf stats
+const statsRetention = 365 * 24 * time.Hour
+
+// maxOpens caps how many individual opens are kept per repository
+const maxOpens = 100
+
+// maxQueries caps how many search queries are remembered
+const maxQueries = 100
+
+// Entry is how often and how recently a repository was opened
+type Entry struct {
+	Path       string  `json:"path"`
+	Rank       float64 `json:"rank"`             // number of opens, reduced by aging; 0 once forgotten
+	Count      int     `json:"count"`            // number of opens, never aged
+	LastOpened int64   `json:"last_opened"`      // unix seconds
+	Remote     string  `json:"remote,omitempty"` // remote URL, to follow the repository when it moves
+	Opens      []Open  `json:"opens,omitempty"`  // the last maxOpens opens, oldest first
+}
+
+// Action is how a repository was opened
+type Action string
+
+const (
+	ActionEditor      Action = "editor"
+	ActionTerminal    Action = "terminal"
+	ActionBrowser     Action = "browser" // its remote, rather
+	ActionFileManager Action = "file_manager"
 )
 
-const MaxRecent = 10
+// Open is a single time a repository was opened
+type Open struct {
+	At     int64  `json:"at"` // unix seconds
+	Action Action `json:"action"`
+}
 
+// Recent is the frecency store of opened repositories: repositories opened
+// often and recently score highest.
 type Recent struct {
+	Entries []Entry  `json:"entries"`
+	Pinned  []string `json:"pinned,omitempty"`  // paths always listed first, in the order pinned
+	Queries []string `json:"queries,omitempty"` // searches that led to an open, oldest first
+
+	pending []change // made since loading, not saved yet
+}
+
+// change is a modification of the history, replayed onto the file on Save
+type change func(r *Recent)
+
+// legacyRecent is the format used before frecency: the last few paths opened,
+// most recent first
+type legacyRecent struct {
+	Entries      []Entry  `json:"entries"`
+	Pinned       []string `json:"pinned"`
+	Queries      []string `json:"queries"`
 	Repositories []string `json:"repositories"`
 }
 
@@ -27,60 +92,257 @@ func LoadRecent() (*Recent, error) {
 		return nil, err
 	}
 
+	return loadRecent(recentPath, time.Now())
+}
+
+func loadRecent(recentPath string, now time.Time) (*Recent, error) {
 	data, err := os.ReadFile(recentPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &Recent{Repositories: []string{}}, nil
+			return &Recent{}, nil
 		}
 		return nil, fmt.Errorf("failed to read recent file: %w", err)
 	}
 
-	var recent Recent
-	err = json.Unmarshal(data, &recent)
+	recent, err := parseRecent(data, now)
+	if err != nil {
+		// Start over rather than lose the ordering; the corrupt file is
+		// backed up by the next Save
+		return &Recent{}, nil
+	}
+	return recent, nil
+}
+
+func parseRecent(data []byte, now time.Time) (*Recent, error) {
+	var stored legacyRecent
+	err := json.Unmarshal(data, &stored)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal recent data: %w", err)
 	}
-	return &recent, nil
+
+	recent := &Recent{Entries: stored.Entries, Pinned: stored.Pinned, Queries: stored.Queries}
+	if len(recent.Entries) == 0 {
+		// Keep the old order by dating each open a minute before the previous
+		for i, path := range stored.Repositories {
+			opened := now.Add(-time.Duration(i) * time.Minute)
+			recent.Entries = append(recent.Entries, Entry{Path: path, Rank: 1, Count: 1, LastOpened: opened.Unix()})
+		}
+	}
+	return recent, nil
 }
 
+// Save records the changes made since loading in the file. It rereads the
+// file under a lock and applies them to what is there now, so opens recorded
+// by other gitf instances in the meantime are kept. Afterwards r reflects the
+// file.
 func (r *Recent) Save() error {
 	recentPath, err := RecentPath()
 	if err != nil {
 		return err
 	}
 
-	err = os.MkdirAll(filepath.Dir(recentPath), 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	return saveRecent(recentPath, r)
+}
+
+func saveRecent(recentPath string, r *Recent) error {
+	return safefile.Update(recentPath, 0644, func(data []byte) ([]byte, error) {
+		current := &Recent{}
+		if data != nil {
+			parsed, err := parseRecent(data, time.Now())
+			if err != nil {
+				if _, err := safefile.Backup(recentPath); err != nil {
+					return nil, err
+				}
+			} else {
+				current = parsed
+			}
+		}
+
+		for _, apply := range r.pending {
+			apply(current)
+		}
+
+		if current.Entries == nil {
+			current.Entries = []Entry{}
+		}
+		data, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal recent data: %w", err)
+		}
+
+		r.Entries = current.Entries
+		r.Pinned = current.Pinned
+		r.Queries = current.Queries
+		r.pending = nil
+		return data, nil
+	})
+}
+
+// Add records that the repository at repoPath was opened now, with action
+func (r *Recent) Add(repoPath string, action Action) {
+	r.add(repoPath, action, time.Now())
+}
+
+func (r *Recent) add(repoPath string, action Action, now time.Time) {
+	r.apply(func(r *Recent) { r.open(repoPath, action, now) })
+}
+
+// apply makes a change now and again on Save
+func (r *Recent) apply(c change) {
+	c(r)
+	r.pending = append(r.pending, c)
+}
+
+func (r *Recent) open(repoPath string, action Action, now time.Time) {
+	i := r.index(repoPath)
+	if i < 0 {
+		r.Entries = append(r.Entries, Entry{Path: repoPath})
+		i = len(r.Entries) - 1
 	}
+	e := &r.Entries[i]
+	e.Rank++
+	e.Count++
+	e.LastOpened = now.Unix()
+	e.Opens = append(e.Opens, Open{At: now.Unix(), Action: action})
+	e.Opens = trimOpens(e.Opens)
 
-	data, err := json.MarshalIndent(r, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal recent data: %w", err)
+	r.age(now)
+}
+
+// Pin keeps the repository at repoPath at the top of the list
+func (r *Recent) Pin(repoPath string) {
+	r.apply(func(r *Recent) {
+		if !r.IsPinned(repoPath) {
+			r.Pinned = append(r.Pinned, repoPath)
+		}
+	})
+}
+
+// Unpin undoes Pin
+func (r *Recent) Unpin(repoPath string) {
+	r.apply(func(r *Recent) { r.unpin(repoPath) })
+}
+
+func (r *Recent) unpin(repoPath string) {
+	r.Pinned = slices.DeleteFunc(r.Pinned, func(path string) bool { return path == repoPath })
+}
+
+func (r *Recent) IsPinned(repoPath string) bool {
+	return slices.Contains(r.Pinned, repoPath)
+}
+
+// AddQuery remembers a search query, moving it to the end if it was
+// remembered before
+func (r *Recent) AddQuery(query string) {
+	if query == "" {
+		return
 	}
+	r.apply(func(r *Recent) {
+		r.Queries = slices.DeleteFunc(r.Queries, func(q string) bool { return q == query })
+		r.Queries = append(r.Queries, query)
+		if len(r.Queries) > maxQueries {
+			r.Queries = slices.Clone(r.Queries[len(r.Queries)-maxQueries:])
+		}
+	})
+}
 
-	err = os.WriteFile(recentPath, data, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to write recent file: %w", err)
+// trimOpens drops all but the last maxOpens opens
+func trimOpens(opens []Open) []Open {
+	if len(opens) <= maxOpens {
+		return opens
 	}
-	return nil
+	return slices.Clone(opens[len(opens)-maxOpens:])
 }
 
-func (r *Recent) Add(repoPath string) {
-	var filtered []string
-	for _, path := range r.Repositories {
-		if path != repoPath {
-			filtered = append(filtered, path)
+// age scales every rank down once their sum exceeds MaxRank. Entries that
+// fall below 1 drop out of the ranking but keep their counts and opens, until
+// they were last opened longer than statsRetention ago.
+func (r *Recent) age(now time.Time) {
+	var total float64
+	for _, e := range r.Entries {
+		total += e.Rank
+	}
+	if total <= MaxRank {
+		return
+	}
+
+	for i := range r.Entries {
+		r.Entries[i].Rank *= agingFactor
+		if r.Entries[i].Rank < 1 {
+			r.Entries[i].Rank = 0
 		}
 	}
 
-	r.Repositories = append([]string{repoPath}, filtered...)
+	cutoff := now.Add(-statsRetention).Unix()
+	r.Entries = slices.DeleteFunc(r.Entries, func(e Entry) bool {
+		return e.forgotten() && e.LastOpened < cutoff
+	})
+}
 
-	if len(r.Repositories) > MaxRecent {
-		r.Repositories = r.Repositories[:MaxRecent]
+// forgotten reports whether aging dropped e from the ranking
+func (e Entry) forgotten() bool {
+	return e.Rank == 0
+}
+
+// Score is the frecency of the repository at repoPath, or 0 if it was never
+// opened
+func (r *Recent) Score(repoPath string) float64 {
+	for _, e := range r.Entries {
+		if e.Path == repoPath {
+			return e.score(time.Now())
+		}
 	}
+	return 0
 }
 
+// Scores returns the frecency of every repository opened, by path
+func (r *Recent) Scores() map[string]float64 {
+	return r.scores(time.Now())
+}
+
+func (r *Recent) scores(now time.Time) map[string]float64 {
+	scores := make(map[string]float64, len(r.Entries))
+	for _, e := range r.Entries {
+		if !e.forgotten() {
+			scores[e.Path] = e.score(now)
+		}
+	}
+	return scores
+}
+
+// score weighs the rank by how long ago the repository was last opened,
+// using the same buckets as zoxide
+func (e Entry) score(now time.Time) float64 {
+	age := now.Sub(time.Unix(e.LastOpened, 0))
+	switch {
+	case age < time.Hour:
+		return e.Rank * 4
+	case age < 24*time.Hour:
+		return e.Rank * 2
+	case age < 7*24*time.Hour:
+		return e.Rank / 2
+	}
+	return e.Rank / 4
+}
+
+// GetRecent returns the paths of all repositories ranked, highest score
+// first and the most recently opened first among equal scores
 func (r *Recent) GetRecent() []string {
-	return r.Repositories
+	return r.ranked(time.Now())
+}
+
+func (r *Recent) ranked(now time.Time) []string {
+	entries := slices.DeleteFunc(slices.Clone(r.Entries), Entry.forgotten)
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		if c := cmp.Compare(b.score(now), a.score(now)); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.LastOpened, a.LastOpened)
+	})
+
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	return paths
 }
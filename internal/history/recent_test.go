@@ -0,0 +1,242 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRanked_FrequentBeatsRecent(t *testing.T) {
+	now := time.Now()
+	r := &Recent{}
+
+	// Opened a lot over the last day, but not in the last hour
+	for range 5 {
+		r.add("/repos/daily", ActionEditor, now.Add(-2*time.Hour))
+	}
+	r.add("/repos/once", ActionEditor, now)
+	r.add("/repos/stale", ActionEditor, now.Add(-30*24*time.Hour))
+
+	got := r.ranked(now)
+	want := []string{"/repos/daily", "/repos/once", "/repos/stale"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestScore_DecaysWithTime(t *testing.T) {
+	now := time.Now()
+	entry := Entry{Path: "/repos/a", Rank: 8}
+
+	tests := []struct {
+		age  time.Duration
+		want float64
+	}{
+		{time.Minute, 32},
+		{3 * time.Hour, 16},
+		{3 * 24 * time.Hour, 4},
+		{30 * 24 * time.Hour, 2},
+	}
+
+	for _, tt := range tests {
+		entry.LastOpened = now.Add(-tt.age).Unix()
+		if got := entry.score(now); got != tt.want {
+			t.Errorf("age %v: expected score %v, got %v", tt.age, tt.want, got)
+		}
+	}
+}
+
+func TestAdd_AgesRanksPastMaxRank(t *testing.T) {
+	now := time.Now()
+	r := &Recent{Entries: []Entry{
+		{Path: "/repos/busy", Rank: MaxRank - 1, LastOpened: now.Unix()},
+		{Path: "/repos/rare", Rank: 1, LastOpened: now.Unix()},
+	}}
+
+	r.add("/repos/busy", ActionEditor, now)
+
+	if ranked := r.GetRecent(); len(ranked) != 1 || ranked[0] != "/repos/busy" {
+		t.Fatalf("expected only /repos/busy to stay ranked, got %v", ranked)
+	}
+	if busy, _ := r.Entry("/repos/busy"); busy.Rank != MaxRank*agingFactor {
+		t.Errorf("expected rank %v, got %v", MaxRank*agingFactor, busy.Rank)
+	}
+	if _, ok := r.Entry("/repos/rare"); !ok {
+		t.Errorf("expected /repos/rare to be kept for its stats")
+	}
+}
+
+func TestAdd_DropsForgottenEntriesPastRetention(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-statsRetention - time.Hour).Unix()
+	r := &Recent{Entries: []Entry{
+		{Path: "/repos/busy", Rank: MaxRank - 1, LastOpened: now.Unix()},
+		{Path: "/repos/stale", Rank: 1, LastOpened: old},
+		{Path: "/repos/recent", Rank: 1, LastOpened: now.Unix()},
+	}}
+
+	r.add("/repos/busy", ActionEditor, now)
+
+	if _, ok := r.Entry("/repos/stale"); ok {
+		t.Errorf("expected /repos/stale to be dropped")
+	}
+	if _, ok := r.Entry("/repos/recent"); !ok {
+		t.Errorf("expected /repos/recent to be kept")
+	}
+}
+
+func TestLoadRecent_MigratesOldFormat(t *testing.T) {
+	recentPath := filepath.Join(t.TempDir(), "recent.json")
+	old := `{"repositories": ["/repos/newest", "/repos/middle", "/repos/oldest"]}`
+	if err := os.WriteFile(recentPath, []byte(old), 0644); err != nil {
+		t.Fatalf("failed to write recent file: %v", err)
+	}
+
+	now := time.Now()
+	r, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+
+	got := r.ranked(now)
+	want := []string{"/repos/newest", "/repos/middle", "/repos/oldest"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSaveRecent_RoundTrip(t *testing.T) {
+	recentPath := filepath.Join(t.TempDir(), "gitf", "recent.json")
+	now := time.Now()
+
+	r := &Recent{}
+	r.add("/repos/a", ActionEditor, now)
+	r.add("/repos/a", ActionEditor, now)
+	if err := saveRecent(recentPath, r); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+
+	loaded, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+	if len(loaded.Entries) != 1 || loaded.Entries[0].Rank != 2 {
+		t.Errorf("expected one entry with rank 2, got %+v", loaded.Entries)
+	}
+}
+
+func TestSaveRecent_MergesConcurrentOpens(t *testing.T) {
+	recentPath := filepath.Join(t.TempDir(), "recent.json")
+	now := time.Now()
+
+	// Two instances load the same history before either saves
+	a, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+	b, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+
+	a.add("/repos/shared", ActionEditor, now)
+	a.add("/repos/a", ActionEditor, now)
+	b.add("/repos/shared", ActionEditor, now)
+	if err := saveRecent(recentPath, a); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+	if err := saveRecent(recentPath, b); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+
+	loaded, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+	if got := loaded.Score("/repos/shared"); got != 8 {
+		t.Errorf("expected both opens of /repos/shared to count, got score %v", got)
+	}
+	if got := loaded.Score("/repos/a"); got != 4 {
+		t.Errorf("expected /repos/a to be kept, got score %v", got)
+	}
+}
+
+func TestLoadRecent_RecoversFromCorruptFile(t *testing.T) {
+	recentPath := filepath.Join(t.TempDir(), "recent.json")
+	if err := os.WriteFile(recentPath, []byte(`{"entries": [{"pa`), 0644); err != nil {
+		t.Fatalf("failed to write recent file: %v", err)
+	}
+
+	now := time.Now()
+	r, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("expected a corrupt file to be ignored, got %v", err)
+	}
+	if len(r.Entries) != 0 {
+		t.Errorf("expected no entries, got %+v", r.Entries)
+	}
+
+	r.add("/repos/a", ActionEditor, now)
+	if err := saveRecent(recentPath, r); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+
+	backups, err := filepath.Glob(recentPath + ".corrupt-*")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("expected the corrupt file to be backed up, got %v", backups)
+	}
+}
+
+func TestPin_SurvivesConcurrentSaves(t *testing.T) {
+	recentPath := filepath.Join(t.TempDir(), "recent.json")
+	now := time.Now()
+
+	a, _ := loadRecent(recentPath, now)
+	b, _ := loadRecent(recentPath, now)
+
+	a.Pin("/repos/a")
+	b.add("/repos/b", ActionEditor, now)
+	if err := saveRecent(recentPath, a); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+	if err := saveRecent(recentPath, b); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+
+	loaded, err := loadRecent(recentPath, now)
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+	if !loaded.IsPinned("/repos/a") {
+		t.Errorf("expected /repos/a to stay pinned, got %v", loaded.Pinned)
+	}
+
+	loaded.Unpin("/repos/a")
+	if loaded.IsPinned("/repos/a") {
+		t.Errorf("expected /repos/a to be unpinned, got %v", loaded.Pinned)
+	}
+}
+
+func TestAddQuery_MovesRepeatsToEnd(t *testing.T) {
+	recentPath := filepath.Join(t.TempDir(), "recent.json")
+
+	r := &Recent{}
+	r.AddQuery("api")
+	r.AddQuery("web")
+	r.AddQuery("api")
+	r.AddQuery("")
+	if err := saveRecent(recentPath, r); err != nil {
+		t.Fatalf("saveRecent failed: %v", err)
+	}
+
+	loaded, err := loadRecent(recentPath, time.Now())
+	if err != nil {
+		t.Fatalf("loadRecent failed: %v", err)
+	}
+	want := []string{"web", "api"}
+	if !slices.Equal(loaded.Queries, want) {
+		t.Errorf("expected %v, got %v", want, loaded.Queries)
+	}
+}
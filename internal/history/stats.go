@@ -0,0 +1,95 @@
+package history
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Interval is the length of the periods opens are counted in
+type Interval int
+
+const (
+	Day Interval = iota
+	Week
+)
+
+// Period is how often repositories were opened in a day or week
+type Period struct {
+	Start time.Time
+	Opens int
+}
+
+// MostOpened returns the n entries opened most often, most first
+func (r *Recent) MostOpened(n int) []Entry {
+	entries := slices.Clone(r.Entries)
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.LastOpened, a.LastOpened)
+	})
+	return entries[:min(n, len(entries))]
+}
+
+// OpensPer counts the opens in each of the last n days or weeks up to now,
+// oldest first. Periods start at midnight in now's location; weeks start on
+// Monday.
+func (r *Recent) OpensPer(interval Interval, n int, now time.Time) []Period {
+	periods := make([]Period, n)
+	current := interval.start(now)
+	for i := range periods {
+		periods[i].Start = interval.add(current, i-n+1)
+	}
+
+	for _, e := range r.Entries {
+		for _, open := range e.Opens {
+			at := time.Unix(open.At, 0).In(now.Location())
+			for i := n - 1; i >= 0; i-- {
+				if !at.Before(periods[i].Start) {
+					periods[i].Opens++
+					break
+				}
+			}
+		}
+	}
+	return periods
+}
+
+// ActionCounts counts the opens recorded by how they were made
+func (r *Recent) ActionCounts() map[Action]int {
+	counts := make(map[Action]int)
+	for _, e := range r.Entries {
+		for _, open := range e.Opens {
+			counts[open.Action]++
+		}
+	}
+	return counts
+}
+
+// Entry returns the entry of the repository at repoPath, if it was opened
+func (r *Recent) Entry(repoPath string) (Entry, bool) {
+	i := r.index(repoPath)
+	if i < 0 {
+		return Entry{}, false
+	}
+	return r.Entries[i], true
+}
+
+// start returns the start of the period t falls in
+func (i Interval) start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if i == Week {
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+// add moves the period start t by n periods, keeping midnight across
+// daylight saving changes
+func (i Interval) add(t time.Time, n int) time.Time {
+	if i == Week {
+		n *= 7
+	}
+	return t.AddDate(0, 0, n)
+}
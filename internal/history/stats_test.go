@@ -0,0 +1,98 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMostOpened(t *testing.T) {
+	now := time.Now()
+	r := &Recent{}
+	for range 3 {
+		r.add("/repos/a", ActionEditor, now)
+	}
+	r.add("/repos/b", ActionTerminal, now)
+	for range 2 {
+		r.add("/repos/c", ActionBrowser, now)
+	}
+
+	top := r.MostOpened(2)
+	if len(top) != 2 || top[0].Path != "/repos/a" || top[1].Path != "/repos/c" {
+		t.Errorf("expected /repos/a and /repos/c, got %+v", top)
+	}
+
+	counts := r.ActionCounts()
+	if counts[ActionEditor] != 3 || counts[ActionTerminal] != 1 || counts[ActionBrowser] != 2 {
+		t.Errorf("unexpected action counts %v", counts)
+	}
+}
+
+func TestOpensPer(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2024, 5, 15, 15, 0, 0, 0, time.UTC)
+
+	r := &Recent{}
+	r.add("/repos/a", ActionEditor, now.Add(-time.Hour))                           // today
+	r.add("/repos/a", ActionEditor, now.Add(-16*time.Hour))                        // yesterday, 23:00
+	r.add("/repos/b", ActionEditor, time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC))  // Monday
+	r.add("/repos/b", ActionEditor, time.Date(2024, 5, 12, 23, 0, 0, 0, time.UTC)) // last Sunday
+	r.add("/repos/b", ActionEditor, now.AddDate(0, 0, -30))                        // too long ago
+
+	days := r.OpensPer(Day, 3, now)
+	want := []int{1, 1, 1} // Monday, Tuesday, Wednesday
+	for i, period := range days {
+		if period.Opens != want[i] {
+			t.Errorf("day %s: expected %d opens, got %d", period.Start.Format("Mon"), want[i], period.Opens)
+		}
+	}
+	if days[0].Start.Weekday() != time.Monday {
+		t.Errorf("expected the first day to be Monday, got %s", days[0].Start.Weekday())
+	}
+
+	weeks := r.OpensPer(Week, 2, now)
+	if weeks[1].Start != time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("expected the week to start on Monday, got %v", weeks[1].Start)
+	}
+	if weeks[0].Opens != 1 || weeks[1].Opens != 3 {
+		t.Errorf("expected 1 and 3 opens, got %d and %d", weeks[0].Opens, weeks[1].Opens)
+	}
+}
+
+func TestAdd_KeepsLastOpens(t *testing.T) {
+	now := time.Now()
+	r := &Recent{}
+	for i := range maxOpens + 5 {
+		r.add("/repos/a", ActionEditor, now.Add(time.Duration(i)*time.Second))
+	}
+
+	e, _ := r.Entry("/repos/a")
+	if e.Count != maxOpens+5 || len(e.Opens) != maxOpens {
+		t.Errorf("expected %d opens counted and %d kept, got %d and %d", maxOpens+5, maxOpens, e.Count, len(e.Opens))
+	}
+	if e.Opens[len(e.Opens)-1].At != e.LastOpened {
+		t.Errorf("expected the latest open to be kept")
+	}
+}
+
+func TestStats_SurviveAging(t *testing.T) {
+	now := time.Now()
+	r := &Recent{}
+	r.add("/repos/rare", ActionTerminal, now.Add(-time.Hour))
+	r.Entries = append(r.Entries, Entry{Path: "/repos/busy", Rank: MaxRank, Count: 1, LastOpened: now.Unix()})
+
+	r.add("/repos/busy", ActionEditor, now)
+	if e, _ := r.Entry("/repos/rare"); e.Rank != 0 {
+		t.Fatalf("expected /repos/rare to be aged out of the ranking, got rank %v", e.Rank)
+	}
+
+	top := r.MostOpened(2)
+	if len(top) != 2 || top[1].Path != "/repos/rare" || top[1].Count != 1 {
+		t.Errorf("expected /repos/rare with 1 open, got %+v", top)
+	}
+	if counts := r.ActionCounts(); counts[ActionTerminal] != 1 {
+		t.Errorf("expected 1 terminal open, got %d", counts[ActionTerminal])
+	}
+	if days := r.OpensPer(Day, 2, now); days[0].Opens+days[1].Opens != 2 {
+		t.Errorf("expected both opens to be counted, got %+v", days)
+	}
+}
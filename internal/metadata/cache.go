@@ -0,0 +1,132 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/safefile"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+// maxAge bounds how long metadata is trusted even if the VCS data didn't
+// change, since files can be added or grow without a commit
+const maxAge = 24 * time.Hour
+
+// Cache holds collected metadata by repository path. It is safe for
+// concurrent use.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]Metadata
+	dirty   bool
+}
+
+func NewCache() *Cache {
+	return &Cache{entries: make(map[string]Metadata)}
+}
+
+func CachePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return filepath.Join(configDir, "gitf", "metadata.json"), nil
+}
+
+// LoadCache reads the cache from disk. A missing cache is not an error; an
+// empty one is returned.
+func LoadCache() (*Cache, error) {
+	cachePath, err := CachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadCache(cachePath)
+}
+
+func loadCache(cachePath string) (*Cache, error) {
+	cache := NewCache()
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+		return nil, fmt.Errorf("failed to read metadata cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata cache: %w", err)
+	}
+	return cache, nil
+}
+
+// Get returns the cached metadata of repo, if any, and whether it is still
+// fresh. Stale metadata is still worth showing until it is collected again.
+func (c *Cache) Get(repo scanner.Repository) (data Metadata, fresh, ok bool) {
+	c.mu.Lock()
+	data, ok = c.entries[repo.Path]
+	c.mu.Unlock()
+
+	if !ok {
+		return Metadata{}, false, false
+	}
+	fresh = data.Stamp == stamp(repo) && time.Since(data.Collected) < maxAge
+	return data, fresh, true
+}
+
+// Peek returns the cached metadata stored for path without checking whether
+// it is still fresh, which makes it cheap enough for rendering and sorting
+func (c *Cache) Peek(path string) (Metadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.entries[path]
+	return data, ok
+}
+
+// Refresh collects the metadata of repo and caches it
+func (c *Cache) Refresh(repo scanner.Repository) Metadata {
+	data := Collect(repo)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[repo.Path] = data
+	c.dirty = true
+	return data
+}
+
+// Save writes the cache to disk if anything was collected since it was loaded
+func (c *Cache) Save() error {
+	cachePath, err := CachePath()
+	if err != nil {
+		return err
+	}
+
+	return c.save(cachePath)
+}
+
+func (c *Cache) save(cachePath string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata cache: %w", err)
+	}
+
+	if err := safefile.Write(cachePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,66 @@
+package metadata
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languages maps source file extensions to the language they are written
+// in. Markup, data and config formats are left out so they don't outvote
+// the code.
+var languages = map[string]string{
+	".c":      "C",
+	".h":      "C",
+	".cc":     "C++",
+	".cpp":    "C++",
+	".cxx":    "C++",
+	".hh":     "C++",
+	".hpp":    "C++",
+	".cs":     "C#",
+	".clj":    "Clojure",
+	".cljs":   "Clojure",
+	".css":    "CSS",
+	".scss":   "CSS",
+	".dart":   "Dart",
+	".ex":     "Elixir",
+	".exs":    "Elixir",
+	".erl":    "Erlang",
+	".go":     "Go",
+	".hs":     "Haskell",
+	".tf":     "HCL",
+	".html":   "HTML",
+	".java":   "Java",
+	".js":     "JavaScript",
+	".jsx":    "JavaScript",
+	".mjs":    "JavaScript",
+	".cjs":    "JavaScript",
+	".jl":     "Julia",
+	".kt":     "Kotlin",
+	".kts":    "Kotlin",
+	".lua":    "Lua",
+	".nix":    "Nix",
+	".ml":     "OCaml",
+	".mli":    "OCaml",
+	".php":    "PHP",
+	".pl":     "Perl",
+	".py":     "Python",
+	".r":      "R",
+	".rb":     "Ruby",
+	".rs":     "Rust",
+	".scala":  "Scala",
+	".sh":     "Shell",
+	".bash":   "Shell",
+	".zsh":    "Shell",
+	".svelte": "Svelte",
+	".swift":  "Swift",
+	".ts":     "TypeScript",
+	".tsx":    "TypeScript",
+	".vue":    "Vue",
+	".zig":    "Zig",
+}
+
+// languageOf returns the language of a file by its extension, or "" if it
+// isn't source code
+func languageOf(name string) string {
+	return languages[strings.ToLower(filepath.Ext(name))]
+}
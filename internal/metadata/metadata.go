@@ -0,0 +1,127 @@
+// Package metadata enriches scanned repositories with details that are too
+// slow to gather during a scan: when they were last committed to, which
+// branch they are on, their main language and their size on disk. Results
+// are cached on disk and only collected again once a repository changes.
+package metadata
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
+)
+
+// Metadata is what enrichment found out about a repository
+type Metadata struct {
+	LastCommit time.Time `json:"last_commit,omitzero"`
+	Branch     string    `json:"branch,omitempty"`
+	Language   string    `json:"language,omitempty"`
+	Size       int64     `json:"size"` // bytes, including VCS data
+
+	Stamp     int64     `json:"stamp"` // mtime of the VCS metadata when collected
+	Collected time.Time `json:"collected"`
+}
+
+// Collect gathers the metadata of repo. Details that can't be determined,
+// like the last commit of an empty repository, are left zero.
+func Collect(repo scanner.Repository) Metadata {
+	data := Metadata{
+		Stamp:     stamp(repo),
+		Collected: time.Now(),
+	}
+
+	if t, err := vcs.GetLastCommitTime(repo); err == nil {
+		data.LastCommit = t
+	}
+	if branch, err := vcs.GetHeadBranch(repo); err == nil {
+		data.Branch = branch
+	}
+
+	data.Size, data.Language = measure(repo)
+	return data
+}
+
+// measure walks the repository once, adding up the size of every file and
+// counting source files by language. Dependencies and VCS data count toward
+// the size but not the language.
+func measure(repo scanner.Repository) (int64, string) {
+	var size int64
+	counts := make(map[string]int)
+
+	filepath.WalkDir(repo.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+
+		if repo.Kind == scanner.KindBare || inIgnoredDir(repo.Path, path) {
+			return nil
+		}
+		if language := languageOf(d.Name()); language != "" {
+			counts[language]++
+		}
+		return nil
+	})
+
+	var dominant string
+	for language, n := range counts {
+		if n > counts[dominant] || (n == counts[dominant] && language < dominant) {
+			dominant = language
+		}
+	}
+	return size, dominant
+}
+
+// inIgnoredDir reports whether path lies in a directory the scanner skips by
+// default, such as node_modules or .git
+func inIgnoredDir(root, path string) bool {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return false
+	}
+
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if scanner.IgnoredByDefault(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// stamp returns the mtime of the directory a VCS touches whenever commits are
+// made, branches switched or changes fetched, so cached metadata can be
+// recognized as outdated
+func stamp(repo scanner.Repository) int64 {
+	dir := repo.Path
+	switch {
+	case repo.Kind == scanner.KindBare:
+	case repo.VCS == scanner.VCSJujutsu:
+		dir = filepath.Join(repo.Path, ".jj", "repo", "op_heads", "heads")
+	case repo.VCS == scanner.VCSMercurial:
+		dir = filepath.Join(repo.Path, ".hg")
+	case repo.VCS == scanner.VCSFossil:
+		// The checkout database is rewritten on every change
+		dir = filepath.Join(repo.Path, ".fslckout")
+	default:
+		dir = scanner.GitDir(repo.Path)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return 0
+	}
+	return info.ModTime().UnixNano()
+}
@@ -0,0 +1,103 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func writeFile(t *testing.T, path string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestMeasure_SizeAndDominantLanguage(t *testing.T) {
+	repoPath := t.TempDir()
+	writeFile(t, filepath.Join(repoPath, ".git", "HEAD"), 10)
+	writeFile(t, filepath.Join(repoPath, "main.go"), 100)
+	writeFile(t, filepath.Join(repoPath, "pkg", "util.go"), 100)
+	writeFile(t, filepath.Join(repoPath, "web", "app.ts"), 100)
+	writeFile(t, filepath.Join(repoPath, "README.md"), 50)
+
+	// Dependencies add to the size but must not outvote the project's code
+	for _, name := range []string{"a.js", "b.js", "c.js"} {
+		writeFile(t, filepath.Join(repoPath, "node_modules", "dep", name), 10)
+	}
+
+	size, language := measure(scanner.Repository{Path: repoPath, VCS: scanner.VCSGit, Kind: scanner.KindMain})
+
+	if size != 390 {
+		t.Errorf("expected size 390, got %d", size)
+	}
+	if language != "Go" {
+		t.Errorf("expected Go, got %q", language)
+	}
+}
+
+func TestCache_FreshUntilRepositoryChanges(t *testing.T) {
+	repoPath := t.TempDir()
+	gitDir := filepath.Join(repoPath, ".git")
+	writeFile(t, filepath.Join(gitDir, "HEAD"), 10)
+	repo := scanner.Repository{Path: repoPath, VCS: scanner.VCSGit, Kind: scanner.KindMain}
+
+	cache := NewCache()
+	if _, _, ok := cache.Get(repo); ok {
+		t.Fatal("expected empty cache")
+	}
+
+	cache.Refresh(repo)
+	if _, fresh, ok := cache.Get(repo); !ok || !fresh {
+		t.Fatalf("expected fresh metadata after refresh, got ok=%v fresh=%v", ok, fresh)
+	}
+
+	// A commit or fetch touches the git directory
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(gitDir, later, later)
+
+	if _, fresh, ok := cache.Get(repo); !ok || fresh {
+		t.Errorf("expected stale metadata after the git directory changed, got ok=%v fresh=%v", ok, fresh)
+	}
+}
+
+func TestCache_SaveLoadRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoPath := filepath.Join(tmpDir, "repo")
+	writeFile(t, filepath.Join(repoPath, "lib.rs"), 20)
+	repo := scanner.Repository{Path: repoPath, VCS: scanner.VCSGit, Kind: scanner.KindMain}
+
+	cache := NewCache()
+	cache.Refresh(repo)
+
+	cacheFile := filepath.Join(tmpDir, "cache", "metadata.json")
+	if err := cache.save(cacheFile); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	loaded, err := loadCache(cacheFile)
+	if err != nil {
+		t.Fatalf("loadCache() returned error: %v", err)
+	}
+
+	data, ok := loaded.Peek(repoPath)
+	if !ok || data.Language != "Rust" || data.Size != 20 {
+		t.Errorf("expected cached Rust repo of 20 bytes, got %+v", data)
+	}
+}
+
+func TestLoadCache_MissingFile(t *testing.T) {
+	cache, err := loadCache(filepath.Join(t.TempDir(), "metadata.json"))
+	if err != nil {
+		t.Fatalf("loadCache() returned error: %v", err)
+	}
+	if _, ok := cache.Peek("/anything"); ok {
+		t.Error("expected empty cache")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package safefile
+
+import "os"
+
+// lockFile does nothing where files can't be locked; writes are still atomic
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// maxProblemsPerRoot caps how many problems are kept per search root, so an
+// unreadable tree doesn't produce thousands of entries
+const maxProblemsPerRoot = 20
+
+type ProblemKind string
+
+const (
+	ProblemMissing    ProblemKind = "missing"    // search path does not exist
+	ProblemNotDir     ProblemKind = "not_dir"    // search path is not a directory
+	ProblemPermission ProblemKind = "permission" // a directory could not be read
+	ProblemVanished   ProblemKind = "vanished"   // a directory below the search path disappeared during the scan
+	ProblemTimeout    ProblemKind = "timeout"    // the scan gave up before finishing the root
+	ProblemOther      ProblemKind = "error"
+)
+
+// Problem is something that kept a scan from seeing part of a search root
+type Problem struct {
+	Kind ProblemKind
+	Path string
+	Err  string
+}
+
+// RootReport summarizes the scan of a single search root
+type RootReport struct {
+	Path          string
+	Repos         int
+	Dirs          int
+	Duration      time.Duration
+	Problems      []Problem           // the first maxProblemsPerRoot problems
+	ProblemCounts map[ProblemKind]int // all problems, by kind
+}
+
+// Diagnostics describes how a scan went, root by root
+type Diagnostics struct {
+	Roots []RootReport
+}
+
+func newProblem(path string, err error) Problem {
+	kind := ProblemOther
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		kind = ProblemMissing
+	case errors.Is(err, fs.ErrPermission):
+		kind = ProblemPermission
+	}
+	return Problem{Kind: kind, Path: path, Err: err.Error()}
+}
+
+// newWalkProblem is newProblem for an error met while walking below root.
+// A directory missing by then was removed during the scan, which is not the
+// same as a missing search path.
+func newWalkProblem(root *Root, path string, err error) Problem {
+	p := newProblem(path, err)
+	if p.Kind == ProblemMissing && path != root.Path {
+		p.Kind = ProblemVanished
+	}
+	return p
+}
+
+func (r *RootReport) addProblem(p Problem) {
+	if r.ProblemCounts == nil {
+		r.ProblemCounts = make(map[ProblemKind]int)
+	}
+	r.ProblemCounts[p.Kind]++
+
+	if len(r.Problems) < maxProblemsPerRoot {
+		r.Problems = append(r.Problems, p)
+	}
+}
+
+// Healthy reports whether the root was scanned completely
+func (r RootReport) Healthy() bool {
+	return len(r.ProblemCounts) == 0
+}
+
+// HasProblems reports whether any root was not scanned completely
+func (d *Diagnostics) HasProblems() bool {
+	if d == nil {
+		return false
+	}
+	for _, root := range d.Roots {
+		if !root.Healthy() {
+			return true
+		}
+	}
+	return false
+}
+
+// TimedOut reports whether the scan gave up on any root before finishing it,
+// so repositories it didn't reach are missing
+func (d *Diagnostics) TimedOut() bool {
+	if d == nil {
+		return false
+	}
+	for _, root := range d.Roots {
+		if root.ProblemCounts[ProblemTimeout] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary describes the problems in one line, e.g. for a status bar
+func (d *Diagnostics) Summary() string {
+	if !d.HasProblems() {
+		return ""
+	}
+
+	counts := make(map[ProblemKind]int)
+	for _, root := range d.Roots {
+		for kind, n := range root.ProblemCounts {
+			counts[kind] += n
+		}
+	}
+
+	var parts []string
+	if n := counts[ProblemMissing] + counts[ProblemNotDir]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d search path%s missing", n, plural(n)))
+	}
+	if n := counts[ProblemTimeout]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d search path%s timed out", n, plural(n)))
+	}
+	if n := counts[ProblemPermission]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d director%s unreadable", n, pluralY(n)))
+	}
+	if n := counts[ProblemVanished]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d director%s removed during the scan", n, pluralY(n)))
+	}
+	if n := counts[ProblemOther]; n > 0 {
+		parts = append(parts, fmt.Sprintf("%d scan error%s", n, plural(n)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+func pluralY(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
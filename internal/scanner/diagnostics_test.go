@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRescan_ReportsReposPerRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	work := filepath.Join(tmpDir, "work")
+	personal := filepath.Join(tmpDir, "personal")
+	makeRepo(t, filepath.Join(work, "api"))
+	makeRepo(t, filepath.Join(work, "web"))
+	makeRepo(t, filepath.Join(personal, "dotfiles"))
+
+	index, err := Rescan(context.Background(), []Root{{Path: work}, {Path: personal}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	roots := index.Diagnostics.Roots
+	if len(roots) != 2 {
+		t.Fatalf("expected 2 root reports, got %d", len(roots))
+	}
+	if roots[0].Repos != 2 || roots[1].Repos != 1 {
+		t.Errorf("expected 2 and 1 repos, got %d and %d", roots[0].Repos, roots[1].Repos)
+	}
+	if index.Diagnostics.HasProblems() {
+		t.Errorf("expected no problems, got %q", index.Diagnostics.Summary())
+	}
+}
+
+func TestRescan_ReportsMissingRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	makeRepo(t, filepath.Join(tmpDir, "repo"))
+	missing := filepath.Join(tmpDir, "nope")
+
+	index, err := Rescan(context.Background(), []Root{{Path: missing}, {Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	if len(index.Repositories) != 1 {
+		t.Errorf("expected the valid root to be scanned, got %d repos", len(index.Repositories))
+	}
+
+	report := index.Diagnostics.Roots[0]
+	if report.Path != missing || report.ProblemCounts[ProblemMissing] != 1 {
+		t.Errorf("expected %s to be reported missing, got %+v", missing, report)
+	}
+	if got := index.Diagnostics.Summary(); got != "1 search path missing" {
+		t.Errorf("unexpected summary %q", got)
+	}
+}
+
+func TestRescan_ReportsUnreadableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	tmpDir := t.TempDir()
+	locked := filepath.Join(tmpDir, "locked")
+	makeRepo(t, filepath.Join(locked, "hidden"))
+	makeRepo(t, filepath.Join(tmpDir, "visible"))
+
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("failed to chmod: %v", err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	index, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	report := index.Diagnostics.Roots[0]
+	if report.ProblemCounts[ProblemPermission] != 1 {
+		t.Errorf("expected 1 permission problem, got %+v", report.ProblemCounts)
+	}
+	if report.Repos != 1 {
+		t.Errorf("expected 1 readable repo, got %d", report.Repos)
+	}
+}
+
+func TestRescan_ReportsTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	makeRepo(t, filepath.Join(tmpDir, "repo"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	index, err := Rescan(ctx, []Root{{Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	if n := index.Diagnostics.Roots[0].ProblemCounts[ProblemTimeout]; n != 1 {
+		t.Errorf("expected 1 timeout problem, got %d", n)
+	}
+}
+
+func TestDiagnostics_CapsProblemsPerRoot(t *testing.T) {
+	var report RootReport
+	for range maxProblemsPerRoot + 5 {
+		report.addProblem(Problem{Kind: ProblemPermission})
+	}
+
+	if len(report.Problems) != maxProblemsPerRoot {
+		t.Errorf("expected %d kept problems, got %d", maxProblemsPerRoot, len(report.Problems))
+	}
+	if report.ProblemCounts[ProblemPermission] != maxProblemsPerRoot+5 {
+		t.Errorf("expected all problems counted, got %d", report.ProblemCounts[ProblemPermission])
+	}
+}
+
+func TestDiagnostics_VanishedDirectoriesAreNotMissingRoots(t *testing.T) {
+	root := &Root{Path: "/src"}
+	gone := &fs.PathError{Op: "open", Path: "/src/api", Err: fs.ErrNotExist}
+
+	var report RootReport
+	report.addProblem(newWalkProblem(root, "/src/api", gone))
+	report.addProblem(newWalkProblem(root, "/src", gone))
+
+	if report.ProblemCounts[ProblemVanished] != 1 || report.ProblemCounts[ProblemMissing] != 1 {
+		t.Errorf("expected 1 vanished directory and 1 missing root, got %+v", report.ProblemCounts)
+	}
+
+	d := &Diagnostics{Roots: []RootReport{report}}
+	if got := d.Summary(); got != "1 search path missing, 1 directory removed during the scan" {
+		t.Errorf("unexpected summary %q", got)
+	}
+}
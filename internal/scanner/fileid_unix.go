@@ -0,0 +1,17 @@
+//go:build !windows
+
+package scanner
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIDOf identifies the directory behind info by device and inode, so it is
+// recognized however it was reached
+func fileIDOf(path string, info fs.FileInfo) fileID {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}
+	}
+	return fileID{path: path}
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package scanner
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// fileIDOf identifies the directory behind info. Windows exposes no inode
+// through fs.FileInfo, so the fully resolved path is used instead.
+func fileIDOf(path string, info fs.FileInfo) fileID {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return fileID{path: path}
+}
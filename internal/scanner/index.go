@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/safefile"
+)
+
+// indexVersion is bumped whenever the on-disk layout changes so stale
+// indexes are discarded instead of misread.
+const indexVersion = 4
+
+// Index is the on-disk cache of a previous scan. Besides the repositories it
+// remembers the mtime and subdirectories of every walked directory, so a
+// later Rescan only has to read directories that changed since.
+type Index struct {
+	Version      int                 `json:"version"`
+	Roots        []Root              `json:"roots"`
+	Repositories []Repository        `json:"repositories"`
+	Dirs         map[string]DirState `json:"dirs"`
+	Diagnostics  *Diagnostics        `json:"-"` // from the scan that built the index
+}
+
+// DirState is what the index remembers about a single directory
+type DirState struct {
+	ModTime int64    `json:"mtime"`
+	Subdirs []string `json:"subdirs,omitempty"`
+	VCS     VCS      `json:"vcs,omitempty"`
+	Kind    Kind     `json:"kind,omitempty"` // set when the directory is a repository
+}
+
+func IndexPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config dir: %w", err)
+	}
+	return filepath.Join(configDir, "gitf", "index.json"), nil
+}
+
+// LoadIndex reads the index from disk. A missing or outdated index is not an
+// error; an empty index is returned so the caller falls back to a full scan.
+func LoadIndex() (*Index, error) {
+	indexPath, err := IndexPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadIndex(indexPath)
+}
+
+func loadIndex(indexPath string) (*Index, error) {
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Index{}, nil
+		}
+		return nil, fmt.Errorf("failed to read index file: %w", err)
+	}
+
+	var index Index
+	err = json.Unmarshal(data, &index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal index: %w", err)
+	}
+
+	if index.Version != indexVersion {
+		return &Index{}, nil
+	}
+	return &index, nil
+}
+
+func (idx *Index) Save() error {
+	indexPath, err := IndexPath()
+	if err != nil {
+		return err
+	}
+
+	return saveIndex(indexPath, idx)
+}
+
+// saveIndex replaces the index atomically, so a crash or another gitf
+// instance saving at the same time never leaves a truncated file. Every scan
+// builds a complete index, so the last one saved wins.
+func saveIndex(indexPath string, idx *Index) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+
+	if err := safefile.Write(indexPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index file: %w", err)
+	}
+	return nil
+}
+
+// Covers reports whether the index was built from the same roots, i.e.
+// whether its repositories can be shown before a rescan confirms them.
+func (idx *Index) Covers(roots []Root) bool {
+	return len(idx.Repositories) > 0 && sameLayout(idx.Roots, roots)
+}
@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func makeRepo(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(path, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create repo %s: %v", path, err)
+	}
+}
+
+func TestRescan_FindsNewAndRemovedRepositories(t *testing.T) {
+	tmpDir := t.TempDir()
+	makeRepo(t, filepath.Join(tmpDir, "keep"))
+	makeRepo(t, filepath.Join(tmpDir, "org", "gone"))
+
+	first, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(first.Repositories) != 2 {
+		t.Fatalf("expected 2 repos, got %d", len(first.Repositories))
+	}
+
+	os.RemoveAll(filepath.Join(tmpDir, "org", "gone"))
+	makeRepo(t, filepath.Join(tmpDir, "org", "added"))
+
+	second, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, first)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	var names []string
+	for _, repo := range second.Repositories {
+		names = append(names, repo.Name)
+	}
+	if len(names) != 2 || names[0] != "added" || names[1] != "keep" {
+		t.Errorf("expected [added keep], got %v", names)
+	}
+}
+
+func TestRescan_SkipsUnchangedDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	org := filepath.Join(tmpDir, "org")
+	makeRepo(t, filepath.Join(org, "repo1"))
+
+	first, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	info, err := os.Stat(org)
+	if err != nil {
+		t.Fatalf("failed to stat %s: %v", org, err)
+	}
+
+	// Sneak a repo in without changing the parent's mtime: an incremental
+	// rescan must trust the index and not read the directory again
+	makeRepo(t, filepath.Join(org, "repo2"))
+	os.Chtimes(org, time.Now(), info.ModTime())
+
+	second, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, first)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(second.Repositories) != 1 {
+		t.Errorf("expected cached result with 1 repo, got %d", len(second.Repositories))
+	}
+
+	full, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(full.Repositories) != 2 {
+		t.Errorf("expected full rescan to find 2 repos, got %d", len(full.Repositories))
+	}
+}
+
+func TestIndex_SaveLoadRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	makeRepo(t, filepath.Join(tmpDir, "repo"))
+
+	index, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	indexFile := filepath.Join(tmpDir, "cache", "index.json")
+	if err := saveIndex(indexFile, index); err != nil {
+		t.Fatalf("saveIndex() returned error: %v", err)
+	}
+
+	loaded, err := loadIndex(indexFile)
+	if err != nil {
+		t.Fatalf("loadIndex() returned error: %v", err)
+	}
+
+	if !loaded.Covers([]Root{{Path: tmpDir}}) {
+		t.Error("expected loaded index to cover the original search paths")
+	}
+	if loaded.Covers([]Root{{Path: tmpDir, Nested: true}}) {
+		t.Error("expected loaded index not to cover roots with different options")
+	}
+	if len(loaded.Dirs) != len(index.Dirs) {
+		t.Errorf("expected %d dirs, got %d", len(index.Dirs), len(loaded.Dirs))
+	}
+}
+
+func TestLoadIndex_MissingFile(t *testing.T) {
+	index, err := loadIndex(filepath.Join(t.TempDir(), "index.json"))
+	if err != nil {
+		t.Fatalf("loadIndex() returned error: %v", err)
+	}
+	if len(index.Repositories) != 0 {
+		t.Errorf("expected empty index, got %d repos", len(index.Repositories))
+	}
+}
+
+func TestStream_ReportsEveryRepositoryOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a", "b", "org/c", "org/d"} {
+		makeRepo(t, filepath.Join(tmpDir, name))
+	}
+	if err := os.Symlink(filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	var mu sync.Mutex
+	found := make(map[string]int)
+	index, err := Stream(context.Background(), []Root{{Path: tmpDir, FollowSymlinks: true}}, nil, func(repo Repository) {
+		mu.Lock()
+		defer mu.Unlock()
+		found[repo.Path]++
+	})
+	if err != nil {
+		t.Fatalf("Stream() returned error: %v", err)
+	}
+
+	if len(found) != len(index.Repositories) {
+		t.Fatalf("expected %d streamed repos, got %d", len(index.Repositories), len(found))
+	}
+	for _, repo := range index.Repositories {
+		if found[repo.Path] != 1 {
+			t.Errorf("expected %s to be streamed once, got %d", repo.Path, found[repo.Path])
+		}
+	}
+}
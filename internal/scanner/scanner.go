@@ -1,16 +1,35 @@
 package scanner
 
 import (
+	"context"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
+	"github.com/tiagokriok/Git-Fuzzy/internal/config"
 	"github.com/tiagokriok/Git-Fuzzy/internal/history"
 )
 
+// Kind describes how a repository's working tree is attached to its
+// repository data, e.g. its git directory
+type Kind string
+
+const (
+	KindMain      Kind = "main"      // .git directory, or a --separate-git-dir checkout
+	KindWorktree  Kind = "worktree"  // linked worktree, jj workspace or hg share
+	KindSubmodule Kind = "submodule" // checkout whose git directory lives in a superproject
+	KindBare      Kind = "bare"      // bare repository without a working tree
+)
+
 type Repository struct {
-	Name string
-	Path string
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	VCS    VCS    `json:"vcs"`
+	Kind   Kind   `json:"kind"`
+	Parent string `json:"parent,omitempty"` // path of the enclosing repository, for nested repositories
 }
 
 var ignoredDirs = map[string]bool{
@@ -28,80 +47,287 @@ var ignoredDirs = map[string]bool{
 	"target":       true,
 }
 
-func shouldIgnore(name string) bool {
+// IgnoreRules are glob patterns deciding which directories are skipped while
+// scanning. Patterns without a slash match the directory name; patterns with
+// one match the path relative to the search root. Include wins over Ignore,
+// so it can re-enable a directory ignored by default.
+type IgnoreRules struct {
+	Ignore  []string `json:"ignore,omitempty"`
+	Include []string `json:"include,omitempty"`
+}
+
+// decide returns whether the rules ignore a directory, and whether they had
+// an opinion on it at all
+func (r IgnoreRules) decide(rel, name string) (ignored, decided bool) {
+	if matchAny(r.Include, rel, name) {
+		return false, true
+	}
+	if matchAny(r.Ignore, rel, name) {
+		return true, true
+	}
+	return false, false
+}
+
+func matchAny(patterns []string, rel, name string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldIgnore reports whether dir, found below root, is skipped. The first
+// set of root's rules with an opinion decides; otherwise the built-in
+// ignoredDirs apply. The search root itself is never ignored, and VCS
+// metadata like .git or .jj is always ignored since it is never a checkout.
+func shouldIgnore(root *Root, dir string) bool {
+	if dir == root.Path {
+		return false
+	}
+
+	name := filepath.Base(dir)
+	if isMetadata(name) {
+		return true
+	}
+
+	rel, err := filepath.Rel(root.Path, dir)
+	if err != nil {
+		rel = dir
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, rules := range root.Rules {
+		if ignored, decided := rules.decide(rel, name); decided {
+			return ignored
+		}
+	}
+
 	return ignoredDirs[name]
 }
 
-func isGitRepository(path string) bool {
+// IgnoredByDefault reports whether directories named name are skipped unless
+// the config includes them: VCS metadata, dependencies, caches and the like.
+func IgnoredByDefault(name string) bool {
+	return isMetadata(name) || ignoredDirs[name]
+}
+
+// gitKind returns the kind of git checkout at path, or "" if path is not a
+// git repository. Besides a .git directory it accepts a .git file pointing at
+// the real git directory, as used by worktrees, submodules and
+// --separate-git-dir clones.
+func gitKind(path string) Kind {
 	gitPath := filepath.Join(path, ".git")
 	info, err := os.Stat(gitPath)
-	return err == nil && info.IsDir()
+	if err != nil {
+		if isBareRepository(path) {
+			return KindBare
+		}
+		return ""
+	}
+
+	if info.IsDir() {
+		return KindMain
+	}
+
+	gitDir := GitDir(path)
+	if gitDir == gitPath {
+		return "" // not a gitdir file
+	}
+
+	// Linked worktrees share objects and refs with the main repository
+	// through a commondir file in their private git directory
+	if _, err := os.Stat(filepath.Join(gitDir, "commondir")); err == nil {
+		return KindWorktree
+	}
+
+	if slices.Contains(strings.Split(filepath.ToSlash(gitDir), "/"), "modules") {
+		return KindSubmodule
+	}
+
+	return KindMain
 }
 
-func Scan(searchPaths []string) ([]Repository, error) {
-	var repos []Repository
-	seen := make(map[string]bool)
+// isBareRepository reports whether path is itself a git directory, i.e. has
+// HEAD, objects and refs at its top level
+func isBareRepository(path string) bool {
+	if info, err := os.Stat(filepath.Join(path, "HEAD")); err != nil || info.IsDir() {
+		return false
+	}
 
-	for _, searchPath := range searchPaths {
-		info, err := os.Stat(searchPath)
-		if err != nil {
-			continue
+	for _, dir := range []string{"objects", "refs"} {
+		info, err := os.Stat(filepath.Join(path, dir))
+		if err != nil || !info.IsDir() {
+			return false
 		}
+	}
+	return true
+}
 
-		if !info.IsDir() {
-			continue
-		}
+// GitDir resolves the git directory of the checkout at path, following the
+// .git file of worktrees, submodules and --separate-git-dir clones. It
+// returns path/.git itself unless that is a valid .git file.
+func GitDir(path string) string {
+	dotGit := filepath.Join(path, ".git")
 
-		absPath, err := filepath.Abs(searchPath)
-		if err != nil {
-			continue
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return dotGit // a directory, usually
+	}
+
+	line, _, _ := strings.Cut(string(data), "\n")
+	dir, ok := strings.CutPrefix(strings.TrimSpace(line), "gitdir:")
+	dir = strings.TrimSpace(dir)
+	if !ok || dir == "" {
+		return dotGit
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(path, dir)
+	}
+	return dir
+}
+
+// Root is a search path together with the options it is scanned with
+type Root struct {
+	Path           string        `json:"path"`
+	MaxDepth       int           `json:"max_depth,omitempty"` // 0 for unlimited
+	FollowSymlinks bool          `json:"follow_symlinks,omitempty"`
+	Nested         bool          `json:"nested,omitempty"`
+	Rules          []IgnoreRules `json:"rules,omitempty"` // by precedence, before the built-in defaults
+}
+
+// Roots builds the scan roots for every search path in cfg. Per-path ignore
+// rules take precedence over the global ones.
+func Roots(cfg *config.Config) []Root {
+	global := IgnoreRules{Ignore: cfg.Ignore, Include: cfg.Include}
+
+	roots := make([]Root, len(cfg.SearchPaths))
+	for i, sp := range cfg.SearchPaths {
+		roots[i] = Root{
+			Path:           sp.Path,
+			MaxDepth:       sp.MaxDepth,
+			FollowSymlinks: sp.FollowSymlinks,
+			Nested:         cfg.NestedFor(sp),
+			Rules: []IgnoreRules{
+				{Ignore: sp.Ignore, Include: sp.Include},
+				global,
+			},
 		}
+	}
+	return roots
+}
 
-		err = filepath.Walk(absPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
+// sameLayout reports whether a and b walk the same directories, which is
+// what decides whether an index built for a can be reused for b. Ignore
+// rules don't matter: the index records ignored directories too.
+func sameLayout(a, b []Root) bool {
+	return slices.EqualFunc(a, b, func(x, y Root) bool {
+		return x.Path == y.Path && x.MaxDepth == y.MaxDepth &&
+			x.FollowSymlinks == y.FollowSymlinks && x.Nested == y.Nested
+	})
+}
 
-			if !info.IsDir() {
-				return nil
-			}
+// Scan walks every search path concurrently and returns the git repositories
+// found, sorted by name. A repository reachable through several search paths
+// or symlinks is reported once.
+func Scan(searchPaths []string) ([]Repository, error) {
+	roots := make([]Root, len(searchPaths))
+	for i, searchPath := range searchPaths {
+		roots[i] = Root{Path: searchPath}
+	}
 
-			dirName := filepath.Base(path)
+	index, err := Rescan(context.Background(), roots, nil)
+	if err != nil {
+		return nil, err
+	}
+	return index.Repositories, nil
+}
 
-			if shouldIgnore(dirName) {
-				return filepath.SkipDir
-			}
+// Rescan walks every root like Scan and returns a fresh index, with
+// diagnostics for each root. When prev was built from the same roots,
+// directories whose mtime matches prev are not read again. Roots still being
+// walked when ctx is done are reported as timed out.
+func Rescan(ctx context.Context, roots []Root, prev *Index) (*Index, error) {
+	return Stream(ctx, roots, prev, nil)
+}
 
-			if isGitRepository(path) {
-				repoName := dirName
+// Stream is Rescan, calling found with every repository as soon as it is
+// discovered, so results can be shown before the scan completes. found is
+// called from the scanning goroutines, in no particular order; the returned
+// index has the same repositories, sorted.
+func Stream(ctx context.Context, roots []Root, prev *Index, found func(Repository)) (*Index, error) {
+	var valid []Root
+	reports := make([]*RootReport, len(roots))
+	walked := make([]int, len(roots)) // index into valid, or -1
 
-				if !seen[path] {
-					seen[path] = true
-					repos = append(repos, Repository{
-						Name: repoName,
-						Path: path,
-					})
-				}
+	for i, root := range roots {
+		walked[i] = -1
+		reports[i] = &RootReport{Path: root.Path}
 
-				return filepath.SkipDir
-			}
+		info, err := os.Stat(root.Path)
+		if err != nil {
+			reports[i].addProblem(newProblem(root.Path, err))
+			continue
+		}
 
-			return nil
-		})
+		if !info.IsDir() {
+			reports[i].addProblem(Problem{Kind: ProblemNotDir, Path: root.Path, Err: "not a directory"})
+			continue
+		}
 
+		absPath, err := filepath.Abs(root.Path)
 		if err != nil {
+			reports[i].addProblem(newProblem(root.Path, err))
 			continue
 		}
 
+		root.Path = absPath
+		walked[i] = len(valid)
+		valid = append(valid, root)
+	}
+
+	var prevDirs map[string]DirState
+	if prev != nil && sameLayout(prev.Roots, roots) {
+		prevDirs = prev.Dirs
+	}
+
+	workers := defaultWorkers()
+	w := newWalker(ctx, workers, prevDirs, found)
+	repos, walkReports := w.run(valid, workers)
+
+	diagnostics := &Diagnostics{Roots: make([]RootReport, len(roots))}
+	for i := range roots {
+		if walked[i] >= 0 {
+			diagnostics.Roots[i] = walkReports[walked[i]]
+		} else {
+			diagnostics.Roots[i] = *reports[i]
+		}
 	}
 
 	sort.Slice(repos, func(i, j int) bool {
-		return repos[i].Name < repos[j].Name
+		if repos[i].Name != repos[j].Name {
+			return repos[i].Name < repos[j].Name
+		}
+		return repos[i].Path < repos[j].Path
 	})
 
-	return repos, nil
+	return &Index{
+		Version:      indexVersion,
+		Roots:        roots,
+		Repositories: repos,
+		Dirs:         w.dirs,
+		Diagnostics:  diagnostics,
+	}, nil
 }
 
+// ReorderByRecent sorts repos in place: pinned repositories first, then by
+// frecency, so repositories opened often and recently come first.
+// Repositories never opened follow by name.
 func ReorderByRecent(repos []Repository, recent *history.Recent) []Repository {
 	recentPaths := recent.GetRecent()
 
@@ -110,7 +336,12 @@ func ReorderByRecent(repos []Repository, recent *history.Recent) []Repository {
 		recentMap[path] = i
 	}
 
-	sort.Slice(repos, func(i, j int) bool {
+	sort.SliceStable(repos, func(i, j int) bool {
+		pinnedI, pinnedJ := recent.IsPinned(repos[i].Path), recent.IsPinned(repos[j].Path)
+		if pinnedI != pinnedJ {
+			return pinnedI
+		}
+
 		posI, inI := recentMap[repos[i].Path]
 		posJ, inJ := recentMap[repos[j].Path]
 
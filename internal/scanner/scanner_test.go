@@ -1,9 +1,16 @@
 package scanner
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/history"
 )
 
 func TestScan_FindsRepositories(t *testing.T) {
@@ -102,3 +109,575 @@ func TestScan_EmptySearchPaths(t *testing.T) {
 		t.Errorf("expected 0 repos, got %d", len(found))
 	}
 }
+
+func TestScan_WalksLargeTreesConcurrently(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	expected := 0
+	for i := range 8 {
+		for j := range 8 {
+			repoPath := filepath.Join(tmpDir, fmt.Sprintf("org%d", i), "team", fmt.Sprintf("repo%d", j))
+			os.MkdirAll(filepath.Join(repoPath, ".git"), 0755)
+			os.WriteFile(filepath.Join(repoPath, "README.md"), []byte("readme"), 0644)
+			expected++
+		}
+	}
+
+	found, err := Scan([]string{tmpDir})
+
+	if err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+
+	if len(found) != expected {
+		t.Fatalf("expected %d repos, got %d", expected, len(found))
+	}
+
+	for i := 1; i < len(found); i++ {
+		prev, cur := found[i-1], found[i]
+		if prev.Name > cur.Name || (prev.Name == cur.Name && prev.Path >= cur.Path) {
+			t.Errorf("results not sorted: %q (%s) before %q (%s)", prev.Name, prev.Path, cur.Name, cur.Path)
+		}
+	}
+}
+
+func TestScan_DoesNotDescendIntoRepositories(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	outer := filepath.Join(tmpDir, "outer")
+	os.MkdirAll(filepath.Join(outer, ".git"), 0755)
+	os.MkdirAll(filepath.Join(outer, "inner", ".git"), 0755)
+
+	found, err := Scan([]string{tmpDir})
+
+	if err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+
+	if len(found) != 1 || found[0].Name != "outer" {
+		t.Errorf("expected only 'outer', got %v", found)
+	}
+}
+
+func TestScan_DetectsGitDirFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	main := filepath.Join(tmpDir, "main")
+	os.MkdirAll(filepath.Join(main, ".git", "worktrees", "feature"), 0755)
+	os.WriteFile(filepath.Join(main, ".git", "worktrees", "feature", "commondir"), []byte("../..\n"), 0644)
+	os.MkdirAll(filepath.Join(main, ".git", "modules", "lib"), 0755)
+
+	worktree := filepath.Join(tmpDir, "feature")
+	os.MkdirAll(worktree, 0755)
+	os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+filepath.Join(main, ".git", "worktrees", "feature")+"\n"), 0644)
+
+	submodule := filepath.Join(tmpDir, "lib")
+	os.MkdirAll(submodule, 0755)
+	os.WriteFile(filepath.Join(submodule, ".git"), []byte("gitdir: main/.git/modules/lib\n"), 0644)
+
+	separate := filepath.Join(tmpDir, "separate")
+	os.MkdirAll(separate, 0755)
+	os.WriteFile(filepath.Join(separate, ".git"), []byte("gitdir: /srv/git/separate.git\n"), 0644)
+
+	notRepo := filepath.Join(tmpDir, "stray")
+	os.MkdirAll(notRepo, 0755)
+	os.WriteFile(filepath.Join(notRepo, ".git"), []byte("not a gitdir file\n"), 0644)
+
+	found, err := Scan([]string{tmpDir})
+
+	if err != nil {
+		t.Fatalf("Scan() returned error: %v", err)
+	}
+
+	kinds := make(map[string]Kind)
+	for _, repo := range found {
+		kinds[repo.Name] = repo.Kind
+	}
+
+	expected := map[string]Kind{
+		"feature":  KindWorktree,
+		"lib":      KindSubmodule,
+		"main":     KindMain,
+		"separate": KindMain,
+	}
+	if len(kinds) != len(expected) {
+		t.Fatalf("expected %d repos, got %v", len(expected), kinds)
+	}
+	for name, kind := range expected {
+		if kinds[name] != kind {
+			t.Errorf("%s: expected kind %q, got %q", name, kind, kinds[name])
+		}
+	}
+}
+
+func TestRescan_NestedRepositories(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	mono := filepath.Join(tmpDir, "mono")
+	os.MkdirAll(filepath.Join(mono, ".git"), 0755)
+	vendored := filepath.Join(mono, "third_party", "lib")
+	os.MkdirAll(filepath.Join(vendored, ".git"), 0755)
+	deeper := filepath.Join(vendored, "plugins", "ext")
+	os.MkdirAll(filepath.Join(deeper, ".git"), 0755)
+
+	flat, err := Rescan(context.Background(), []Root{{Path: tmpDir}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(flat.Repositories) != 1 {
+		t.Errorf("expected 1 repo without nested discovery, got %d", len(flat.Repositories))
+	}
+
+	nested, err := Rescan(context.Background(), []Root{{Path: tmpDir, Nested: true}}, flat)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	parents := make(map[string]string)
+	for _, repo := range nested.Repositories {
+		parents[repo.Path] = repo.Parent
+	}
+
+	expected := map[string]string{
+		mono:     "",
+		vendored: mono,
+		deeper:   vendored,
+	}
+	if len(parents) != len(expected) {
+		t.Fatalf("expected %d repos, got %v", len(expected), parents)
+	}
+	for path, parent := range expected {
+		if parents[path] != parent {
+			t.Errorf("%s: expected parent %q, got %q", path, parent, parents[path])
+		}
+	}
+
+	cached, err := Rescan(context.Background(), []Root{{Path: tmpDir, Nested: true}}, nested)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(cached.Repositories) != len(expected) {
+		t.Errorf("expected incremental rescan to keep %d nested repos, got %d", len(expected), len(cached.Repositories))
+	}
+}
+
+func TestScan_DetectsBareRepositories(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bare := filepath.Join(tmpDir, "mirrors", "tool.git")
+	os.MkdirAll(filepath.Join(bare, "objects"), 0755)
+	os.MkdirAll(filepath.Join(bare, "refs", "heads"), 0755)
+	os.WriteFile(filepath.Join(bare, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	// Only HEAD is not enough to be a repository
+	notBare := filepath.Join(tmpDir, "mirrors", "half")
+	os.MkdirAll(notBare, 0755)
+	os.WriteFile(filepath.Join(notBare, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	found, err := Rescan(context.Background(), []Root{{Path: tmpDir, Nested: true}}, nil)
+
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	if len(found.Repositories) != 1 {
+		t.Fatalf("expected 1 repo, got %v", found.Repositories)
+	}
+
+	if found.Repositories[0].Name != "tool.git" || found.Repositories[0].Kind != KindBare {
+		t.Errorf("expected bare 'tool.git', got %+v", found.Repositories[0])
+	}
+}
+
+func TestRescan_DetectsOtherVCS(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Colocated jj checkout, with jj's own git store inside .jj
+	colocated := filepath.Join(tmpDir, "colocated")
+	os.MkdirAll(filepath.Join(colocated, ".git"), 0755)
+	store := filepath.Join(colocated, ".jj", "repo", "store", "git")
+	os.MkdirAll(filepath.Join(store, "objects"), 0755)
+	os.MkdirAll(filepath.Join(store, "refs"), 0755)
+	os.WriteFile(filepath.Join(store, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	workspace := filepath.Join(tmpDir, "workspace")
+	os.MkdirAll(filepath.Join(workspace, ".jj"), 0755)
+	os.WriteFile(filepath.Join(workspace, ".jj", "repo"), []byte(filepath.Join(colocated, ".jj", "repo")), 0644)
+
+	os.MkdirAll(filepath.Join(tmpDir, "hgclone", ".hg"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "hgshare", ".hg"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "hgshare", ".hg", "sharedpath"), []byte(filepath.Join(tmpDir, "hgclone", ".hg")), 0644)
+
+	os.MkdirAll(filepath.Join(tmpDir, "fossil"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "fossil", ".fslckout"), nil, 0644)
+
+	found, err := Rescan(context.Background(), []Root{{Path: tmpDir, Nested: true}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	want := map[string]struct {
+		vcs  VCS
+		kind Kind
+	}{
+		"colocated": {VCSJujutsu, KindMain},
+		"fossil":    {VCSFossil, KindMain},
+		"hgclone":   {VCSMercurial, KindMain},
+		"hgshare":   {VCSMercurial, KindWorktree},
+		"workspace": {VCSJujutsu, KindWorktree},
+	}
+
+	if len(found.Repositories) != len(want) {
+		t.Fatalf("expected %d repos, got %+v", len(want), found.Repositories)
+	}
+	for _, repo := range found.Repositories {
+		w, ok := want[repo.Name]
+		if !ok || repo.VCS != w.vcs || repo.Kind != w.kind {
+			t.Errorf("unexpected %s: got %s/%s, want %s/%s", repo.Name, repo.VCS, repo.Kind, w.vcs, w.kind)
+		}
+	}
+}
+
+func TestRescan_IgnoreAndIncludeRules(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, repo := range []string{
+		".config/nvim",
+		"node_modules/pkg",
+		"build/out",
+		"bazel-bin/gen",
+		"third_party/keep",
+		"third_party/skip/docs",
+		"app",
+	} {
+		os.MkdirAll(filepath.Join(tmpDir, filepath.FromSlash(repo), ".git"), 0755)
+	}
+
+	roots := []Root{{
+		Path: tmpDir,
+		Rules: []IgnoreRules{
+			{Ignore: []string{"third_party/skip"}},
+			{Ignore: []string{"build", "bazel-*"}, Include: []string{".config", "third_party/*"}},
+		},
+	}}
+
+	found, err := Rescan(context.Background(), roots, nil)
+
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	var names []string
+	for _, repo := range found.Repositories {
+		names = append(names, repo.Name)
+	}
+
+	expected := []string{"app", "keep", "nvim"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestRescan_NeverIgnoresSearchRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	root := filepath.Join(tmpDir, ".config")
+	os.MkdirAll(filepath.Join(root, "nvim", ".git"), 0755)
+
+	found, err := Rescan(context.Background(), []Root{{Path: root}}, nil)
+
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	if len(found.Repositories) != 1 {
+		t.Errorf("expected 1 repo inside the search root, got %d", len(found.Repositories))
+	}
+}
+
+func TestRescan_MaxDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "shallow", ".git"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "org", "mid", ".git"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "org", "team", "deep", ".git"), 0755)
+
+	for depth, expected := range map[int]int{0: 3, 1: 1, 2: 2, 3: 3} {
+		found, err := Rescan(context.Background(), []Root{{Path: tmpDir, MaxDepth: depth}}, nil)
+		if err != nil {
+			t.Fatalf("Rescan() returned error: %v", err)
+		}
+
+		if len(found.Repositories) != expected {
+			t.Errorf("max_depth %d: expected %d repos, got %d", depth, expected, len(found.Repositories))
+		}
+	}
+}
+
+func TestRescan_FollowSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ssd := filepath.Join(tmpDir, "ssd", "work")
+	os.MkdirAll(filepath.Join(ssd, "api", ".git"), 0755)
+
+	home := filepath.Join(tmpDir, "home")
+	os.MkdirAll(home, 0755)
+	if err := os.Symlink(ssd, filepath.Join(home, "work")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	// A link back up the tree must not be walked forever
+	os.Symlink(home, filepath.Join(ssd, "loop"))
+
+	plain, err := Rescan(context.Background(), []Root{{Path: home}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(plain.Repositories) != 0 {
+		t.Errorf("expected symlinks not to be followed by default, got %v", plain.Repositories)
+	}
+
+	roots := []Root{{Path: home, FollowSymlinks: true}}
+	followed, err := Rescan(context.Background(), roots, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	expected := filepath.Join(home, "work", "api")
+	if len(followed.Repositories) != 1 || followed.Repositories[0].Path != expected {
+		t.Fatalf("expected only %s, got %v", expected, followed.Repositories)
+	}
+
+	cached, err := Rescan(context.Background(), roots, followed)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(cached.Repositories) != 1 {
+		t.Errorf("expected incremental rescan to follow the link again, got %v", cached.Repositories)
+	}
+}
+
+func TestRescan_SymlinkedSearchRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	target := filepath.Join(tmpDir, "target")
+	os.MkdirAll(filepath.Join(target, "repo", ".git"), 0755)
+
+	link := filepath.Join(tmpDir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	found, err := Rescan(context.Background(), []Root{{Path: link}}, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	if len(found.Repositories) != 1 || found.Repositories[0].Path != filepath.Join(link, "repo") {
+		t.Errorf("expected repo under the symlinked root, got %v", found.Repositories)
+	}
+}
+
+func TestRescan_OverlappingSearchPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	dev := filepath.Join(tmpDir, "dev")
+	os.MkdirAll(filepath.Join(dev, "tool", ".git"), 0755)
+	work := filepath.Join(dev, "work")
+	os.MkdirAll(filepath.Join(work, "api", ".git"), 0755)
+	os.MkdirAll(filepath.Join(work, "api", "vendor", "lib", ".git"), 0755)
+	os.MkdirAll(filepath.Join(work, "api", "plugins", "ext", ".git"), 0755)
+
+	alias := filepath.Join(tmpDir, "dev-alias")
+	if err := os.Symlink(dev, alias); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	roots := []Root{
+		{Path: dev},
+		{Path: work, Nested: true},
+		{Path: alias},
+		{Path: filepath.Join(tmpDir, "links"), FollowSymlinks: true},
+	}
+	os.MkdirAll(roots[3].Path, 0755)
+	os.Symlink(filepath.Join(work, "api"), filepath.Join(roots[3].Path, "api"))
+
+	found, err := Rescan(context.Background(), roots, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	// The nested root wins inside work/, so ext is found but lib stays
+	// ignored as a vendor directory
+	var names []string
+	for _, repo := range found.Repositories {
+		names = append(names, repo.Name)
+	}
+	expected := []string{"api", "ext", "tool"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestRescan_OverlappingSearchPathsIncremental(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	dev := filepath.Join(tmpDir, "dev")
+	work := filepath.Join(dev, "work")
+	os.MkdirAll(filepath.Join(work, "a", ".git"), 0755)
+	os.MkdirAll(filepath.Join(work, "skipme", "c", ".git"), 0755)
+
+	roots := []Root{
+		{Path: dev},
+		{Path: work, Rules: []IgnoreRules{{Ignore: []string{"skipme"}}}},
+	}
+
+	// The replay of cached subdirectories must leave work/ to its own root
+	// just like the first walk does
+	var prev *Index
+	for scan := range 2 {
+		found, err := Rescan(context.Background(), roots, prev)
+		if err != nil {
+			t.Fatalf("Rescan() returned error: %v", err)
+		}
+
+		var paths []string
+		for _, repo := range found.Repositories {
+			paths = append(paths, repo.Path)
+		}
+		expected := []string{filepath.Join(work, "a")}
+		if fmt.Sprint(paths) != fmt.Sprint(expected) {
+			t.Errorf("scan %d: expected %v, got %v", scan+1, expected, paths)
+		}
+		prev = found
+	}
+}
+
+func TestRescan_OverlappingRootsResolveDeterministically(t *testing.T) {
+	tmpDir := t.TempDir()
+	links := filepath.Join(tmpDir, "links")
+	repos := filepath.Join(tmpDir, "repos")
+	makeRepo(t, filepath.Join(repos, "api"))
+	makeRepo(t, filepath.Join(repos, "api", "plugins"))
+	os.MkdirAll(links, 0755)
+	if err := os.Symlink(filepath.Join(repos, "api"), filepath.Join(links, "api")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// Both roots reach the same repositories, in whatever order the workers
+	// get to them
+	roots := []Root{
+		{Path: repos, Nested: true},
+		{Path: links, Nested: true, FollowSymlinks: true},
+	}
+	expected := []Repository{
+		{Name: "api", Path: filepath.Join(links, "api"), VCS: VCSGit, Kind: KindMain},
+		{Name: "plugins", Path: filepath.Join(links, "api", "plugins"), VCS: VCSGit, Kind: KindMain, Parent: filepath.Join(links, "api")},
+	}
+
+	for scan := range 20 {
+		found, err := Rescan(context.Background(), roots, nil)
+		if err != nil {
+			t.Fatalf("Rescan() returned error: %v", err)
+		}
+		if !slices.Equal(found.Repositories, expected) {
+			t.Fatalf("scan %d: expected %+v, got %+v", scan+1, expected, found.Repositories)
+		}
+	}
+}
+
+func TestRescan_AfterTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := range 10 {
+		for j := range 20 {
+			os.MkdirAll(filepath.Join(tmpDir, fmt.Sprintf("group%d", i), fmt.Sprintf("repo%d", j), ".git"), 0755)
+		}
+	}
+	roots := []Root{{Path: tmpDir}}
+
+	// Give up after a few repositories, as a scan timeout would
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	seen := 0
+	partial, err := Stream(ctx, roots, nil, func(Repository) {
+		mu.Lock()
+		defer mu.Unlock()
+		if seen++; seen == 5 {
+			cancel()
+		}
+	})
+	cancel()
+	if err != nil {
+		t.Fatalf("Stream() returned error: %v", err)
+	}
+	if !partial.Diagnostics.TimedOut() {
+		t.Fatalf("expected the scan to be reported as timed out")
+	}
+
+	// The directories cut short must be read again, not replayed
+	again, err := Rescan(context.Background(), roots, partial)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+	if len(again.Repositories) != 200 {
+		t.Errorf("expected 200 repos after a timed out scan, got %d", len(again.Repositories))
+	}
+}
+
+func TestReorderByRecent_PinnedFirst(t *testing.T) {
+	now := time.Now().Unix()
+	recent := &history.Recent{
+		Entries: []history.Entry{
+			{Path: "/repos/often", Rank: 10, LastOpened: now},
+			{Path: "/repos/once", Rank: 1, LastOpened: now},
+		},
+		Pinned: []string{"/repos/pinned"},
+	}
+
+	repos := []Repository{
+		{Name: "alpha", Path: "/repos/alpha"},
+		{Name: "once", Path: "/repos/once"},
+		{Name: "often", Path: "/repos/often"},
+		{Name: "pinned", Path: "/repos/pinned"},
+	}
+
+	var got []string
+	for _, repo := range ReorderByRecent(repos, recent) {
+		got = append(got, repo.Name)
+	}
+
+	want := []string{"pinned", "often", "once", "alpha"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGitDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	external := filepath.Join(tmpDir, "external.git")
+
+	tests := []struct {
+		name   string
+		dotGit string // content of the .git file, or "" for a directory
+		want   string
+	}{
+		{"directory", "", filepath.Join(tmpDir, "directory", ".git")},
+		{"absolute", "gitdir: " + external + "\n", external},
+		{"relative", "gitdir: ../main/.git/worktrees/relative\n", filepath.Join(tmpDir, "main", ".git", "worktrees", "relative")},
+		{"malformed", "not a gitdir\n", filepath.Join(tmpDir, "malformed", ".git")},
+		{"empty", "gitdir: \n", filepath.Join(tmpDir, "empty", ".git")},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(tmpDir, tt.name)
+		os.MkdirAll(path, 0755)
+		if tt.dotGit == "" {
+			os.MkdirAll(filepath.Join(path, ".git"), 0755)
+		} else if err := os.WriteFile(filepath.Join(path, ".git"), []byte(tt.dotGit), 0644); err != nil {
+			t.Fatalf("failed to write .git file: %v", err)
+		}
+
+		if got := GitDir(path); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// VCS is the version control system a checkout belongs to
+type VCS string
+
+const (
+	VCSGit       VCS = "git"
+	VCSJujutsu   VCS = "jj"
+	VCSMercurial VCS = "hg"
+	VCSFossil    VCS = "fossil"
+)
+
+// detector recognizes the checkouts of one VCS
+type detector struct {
+	vcs      VCS
+	metadata []string          // entries the VCS keeps at the top of a checkout
+	kind     func(string) Kind // "" if the directory isn't a checkout
+}
+
+// detectors are tried in order and the first to recognize a directory wins.
+// Jujutsu goes before git since colocated jj checkouts have a .git as well.
+var detectors = []detector{
+	{vcs: VCSJujutsu, metadata: []string{".jj"}, kind: jujutsuKind},
+	{vcs: VCSGit, metadata: []string{".git"}, kind: gitKind},
+	{vcs: VCSMercurial, metadata: []string{".hg"}, kind: mercurialKind},
+	{vcs: VCSFossil, metadata: []string{".fslckout", "_FOSSIL_"}, kind: fossilKind},
+}
+
+// detectRepository returns the VCS and kind of the checkout at path, or an
+// empty kind if path is not a checkout
+func detectRepository(path string) (VCS, Kind) {
+	for _, d := range detectors {
+		if kind := d.kind(path); kind != "" {
+			return d.vcs, kind
+		}
+	}
+	return "", ""
+}
+
+// isMetadata reports whether name is where some VCS keeps its data
+func isMetadata(name string) bool {
+	for _, d := range detectors {
+		for _, metadata := range d.metadata {
+			if name == metadata {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jujutsuKind recognizes a .jj directory. Workspaces added with
+// jj workspace add have a .jj/repo file pointing at the main workspace.
+func jujutsuKind(path string) Kind {
+	if !isDir(filepath.Join(path, ".jj")) {
+		return ""
+	}
+	if info, err := os.Stat(filepath.Join(path, ".jj", "repo")); err == nil && !info.IsDir() {
+		return KindWorktree
+	}
+	return KindMain
+}
+
+// mercurialKind recognizes a .hg directory. Checkouts created with hg share
+// have a .hg/sharedpath file pointing at the repository they share.
+func mercurialKind(path string) Kind {
+	if !isDir(filepath.Join(path, ".hg")) {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(path, ".hg", "sharedpath")); err == nil {
+		return KindWorktree
+	}
+	return KindMain
+}
+
+// fossilKind recognizes the checkout database fossil open creates, named
+// _FOSSIL_ on Windows
+func fossilKind(path string) Kind {
+	for _, name := range []string{".fslckout", "_FOSSIL_"} {
+		if info, err := os.Stat(filepath.Join(path, name)); err == nil && !info.IsDir() {
+			return KindMain
+		}
+	}
+	return ""
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,455 @@
+package scanner
+
+import (
+	"cmp"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// walker discovers repositories below a set of roots using a fixed pool of
+// workers. Each worker runs filepath.WalkDir over a subtree and hands off
+// subdirectories to idle workers whenever the job queue has room, so large
+// trees are split across the pool without spawning a goroutine per directory.
+//
+// When a previous index is supplied, directories whose mtime is unchanged are
+// not read again: their cached subdirectories are walked directly instead.
+type walker struct {
+	ctx     context.Context
+	start   time.Time
+	jobs    chan job
+	pending sync.WaitGroup
+	prev    map[string]DirState
+	found   func(Repository) // called for every new repository, may be nil
+
+	// roots maps the resolved path of every search root to its Root. A walk
+	// that reaches another root's directory stops there and leaves it to
+	// that root, so overlapping search paths are walked once, each subtree
+	// with the options of the most specific root.
+	roots map[string]*Root
+
+	mu         sync.Mutex
+	seen       map[fileID]bool
+	paths      map[string]bool
+	candidates []candidate
+	dirs       map[string]DirState
+	visited    map[*Root]map[fileID]bool
+	reports    map[*Root]*RootReport
+}
+
+// job is a subtree to walk, along with the search root it belongs to
+type job struct {
+	path  string // as reported, which may go through followed symlinks
+	real  string // what is actually walked
+	root  *Root
+	depth int // levels below root.Path
+}
+
+// candidate is a repository as reached by one path, of possibly several
+type candidate struct {
+	repo Repository
+	root *Root
+	id   fileID
+}
+
+// fileID identifies a directory independently of the path it was reached by
+type fileID struct {
+	dev, ino uint64
+	path     string // fallback where device and inode are unavailable
+}
+
+func defaultWorkers() int {
+	return max(runtime.NumCPU()*2, 4)
+}
+
+func newWalker(ctx context.Context, workers int, prev map[string]DirState, found func(Repository)) *walker {
+	return &walker{
+		ctx:     ctx,
+		jobs:    make(chan job, workers),
+		prev:    prev,
+		found:   found,
+		roots:   make(map[string]*Root),
+		seen:    make(map[fileID]bool),
+		paths:   make(map[string]bool),
+		dirs:    make(map[string]DirState),
+		visited: make(map[*Root]map[fileID]bool),
+		reports: make(map[*Root]*RootReport),
+	}
+}
+
+// run walks every root with the given number of workers and returns the
+// repositories found, deduplicated by device and inode, along with a report
+// for each root.
+func (w *walker) run(roots []Root, workers int) ([]Repository, []RootReport) {
+	w.start = time.Now()
+
+	var jobs []job
+	for i := range roots {
+		w.reports[&roots[i]] = &RootReport{Path: roots[i].Path}
+
+		// WalkDir doesn't descend into a symlinked root, so walk its target
+		real, err := filepath.EvalSymlinks(roots[i].Path)
+		if err != nil {
+			w.problem(&roots[i], newProblem(roots[i].Path, err))
+			continue
+		}
+
+		// The same directory configured twice, possibly through a symlink
+		if _, ok := w.roots[real]; ok {
+			continue
+		}
+
+		w.roots[real] = &roots[i]
+		jobs = append(jobs, job{path: roots[i].Path, real: real, root: &roots[i]})
+	}
+
+	for range workers {
+		go w.work()
+	}
+
+	for _, j := range jobs {
+		w.pending.Add(1)
+		w.jobs <- j
+	}
+
+	w.pending.Wait()
+	close(w.jobs)
+	repos := w.resolve()
+
+	reports := make([]RootReport, len(roots))
+	for i := range roots {
+		reports[i] = *w.reports[&roots[i]]
+	}
+	return repos, reports
+}
+
+func (w *walker) work() {
+	for j := range w.jobs {
+		w.walk(j)
+		w.finish(j.root)
+		w.pending.Done()
+	}
+}
+
+func (w *walker) walk(j job) {
+	filepath.WalkDir(j.real, func(real string, d fs.DirEntry, err error) error {
+		path := j.path + real[len(j.real):]
+
+		if w.ctx.Err() != nil {
+			w.timeout(j.root)
+			w.cutShort(j, path)
+			return fs.SkipAll
+		}
+
+		if err != nil {
+			w.problem(j.root, newWalkProblem(j.root, path, err))
+			// Don't trust a directory we failed to read on the next rescan
+			if d != nil && d.IsDir() {
+				w.forgetDir(path)
+			}
+			return nil
+		}
+
+		depth := j.depth + strings.Count(real[len(j.real):], string(filepath.Separator))
+		tooDeep := j.root.MaxDepth > 0 && depth > j.root.MaxDepth
+
+		if !d.IsDir() {
+			if d.Type()&fs.ModeSymlink != 0 && j.root.FollowSymlinks && !tooDeep {
+				link := job{path: path, real: real, root: j.root, depth: depth}
+				// Record the link as a subdirectory so incremental rescans
+				// follow it again, unless it came from the index already
+				if w.follow(link) && real != j.real {
+					w.addSubdir(filepath.Dir(path), d.Name())
+				}
+			}
+			return nil
+		}
+
+		if tooDeep {
+			return filepath.SkipDir
+		}
+
+		// Subdirectories are checked by whichever worker picks them up
+		if real != j.real {
+			w.addSubdir(filepath.Dir(path), d.Name())
+			if owner, ok := w.roots[real]; ok && owner != j.root {
+				return filepath.SkipDir
+			}
+			if w.offload(job{path: path, real: real, root: j.root, depth: depth}) {
+				return filepath.SkipDir
+			}
+		}
+
+		if shouldIgnore(j.root, path) {
+			return filepath.SkipDir
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return filepath.SkipDir
+		}
+
+		// Followed symlinks can lead back to a directory walked already
+		id := fileIDOf(real, info)
+		if !w.visit(j.root, id) {
+			return filepath.SkipDir
+		}
+
+		modTime := info.ModTime().UnixNano()
+		atMaxDepth := j.root.MaxDepth > 0 && depth == j.root.MaxDepth
+
+		if cached, ok := w.prev[path]; ok && cached.ModTime == modTime {
+			w.setDir(path, cached)
+			if cached.Kind != "" {
+				w.add(j.root, id, Repository{Name: d.Name(), Path: path, VCS: cached.VCS, Kind: cached.Kind})
+				if !descendInto(j.root, cached.Kind) {
+					return filepath.SkipDir
+				}
+			}
+
+			if atMaxDepth {
+				return filepath.SkipDir
+			}
+
+			for _, name := range cached.Subdirs {
+				child := job{
+					path:  filepath.Join(path, name),
+					real:  filepath.Join(real, name),
+					root:  j.root,
+					depth: depth + 1,
+				}
+				// Leave other search roots to themselves, as when walking
+				if owner, ok := w.roots[child.real]; ok && owner != j.root {
+					continue
+				}
+				if !w.offload(child) {
+					w.walk(child)
+				}
+			}
+			return filepath.SkipDir
+		}
+
+		if vcs, kind := detectRepository(path); kind != "" {
+			w.setDir(path, DirState{ModTime: modTime, VCS: vcs, Kind: kind})
+			w.add(j.root, id, Repository{
+				Name: d.Name(),
+				Path: path,
+				VCS:  vcs,
+				Kind: kind,
+			})
+			if !descendInto(j.root, kind) || atMaxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		w.setDir(path, DirState{ModTime: modTime})
+		if atMaxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// follow walks the directory a symlink points at under the symlink's own
+// path, and reports whether the link leads to a directory
+func (w *walker) follow(link job) bool {
+	target, err := filepath.EvalSymlinks(link.real)
+	if err != nil {
+		return false
+	}
+
+	info, err := os.Stat(target)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+
+	// Leave other search roots to themselves, as for plain subdirectories
+	if owner, ok := w.roots[target]; ok && owner != link.root {
+		return true
+	}
+
+	link.real = target
+	if !w.offload(link) {
+		w.walk(link)
+	}
+	return true
+}
+
+// descendInto reports whether the walk continues below a repository of the
+// given kind. A bare repository's subdirectories are git internals, never
+// checkouts, so they are skipped even when nested discovery is enabled.
+func descendInto(root *Root, kind Kind) bool {
+	return root.Nested && kind != KindBare
+}
+
+// offload queues j for another worker if the queue has room. It never
+// blocks, so a busy pool simply keeps walking the subtree itself.
+func (w *walker) offload(j job) bool {
+	w.pending.Add(1)
+	select {
+	case w.jobs <- j:
+		return true
+	default:
+		w.pending.Done()
+		return false
+	}
+}
+
+// add streams repo to found unless the directory identified by id was
+// already found through another path, e.g. a symlink. It links repo to the
+// closest enclosing repository found so far; directories are always visited
+// before their subdirectories, so an enclosing repository is known by then.
+// Which path and parent the result settles on is decided by resolve.
+func (w *walker) add(root *Root, id fileID, repo Repository) {
+	if !w.record(root, id, &repo) {
+		return
+	}
+
+	// Outside the lock, so a slow consumer only holds up this worker
+	if w.found != nil {
+		w.found(repo)
+	}
+}
+
+func (w *walker) record(root *Root, id fileID, repo *Repository) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.candidates = append(w.candidates, candidate{repo: *repo, root: root, id: id})
+	if w.seen[id] {
+		return false
+	}
+	w.seen[id] = true
+	w.paths[repo.Path] = true
+	repo.Parent = closestParent(repo.Path, w.paths)
+	return true
+}
+
+// resolve reports every repository found once, under the first of the paths
+// it was reached by in sorted order, and links it to its closest enclosing
+// repository. Workers find repositories in no particular order, so this is
+// only decided once the walk is over, for rescans to agree with each other.
+func (w *walker) resolve() []Repository {
+	slices.SortFunc(w.candidates, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(a.repo.Path, b.repo.Path), cmp.Compare(a.root.Path, b.root.Path))
+	})
+
+	resolved := make(map[fileID]bool)
+	paths := make(map[string]bool)
+	var repos []Repository
+	for _, c := range w.candidates {
+		if resolved[c.id] {
+			continue
+		}
+		resolved[c.id] = true
+		paths[c.repo.Path] = true
+		w.reports[c.root].Repos++
+		repos = append(repos, c.repo)
+	}
+
+	for i := range repos {
+		repos[i].Parent = closestParent(repos[i].Path, paths)
+	}
+	return repos
+}
+
+// closestParent returns the closest directory above path in repos, or ""
+func closestParent(path string, repos map[string]bool) string {
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if repos[dir] {
+			return dir
+		}
+	}
+	return ""
+}
+
+// visit marks id as walked for root and reports whether it was new
+func (w *walker) visit(root *Root, id fileID) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	visited := w.visited[root]
+	if visited == nil {
+		visited = make(map[fileID]bool)
+		w.visited[root] = visited
+	}
+
+	if visited[id] {
+		return false
+	}
+	visited[id] = true
+	w.reports[root].Dirs++
+	return true
+}
+
+func (w *walker) problem(root *Root, p Problem) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.reports[root].addProblem(p)
+}
+
+// timeout records that root was cut short by the scan deadline, once
+func (w *walker) timeout(root *Root) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	report := w.reports[root]
+	if report.ProblemCounts[ProblemTimeout] == 0 {
+		report.addProblem(Problem{Kind: ProblemTimeout, Path: root.Path, Err: w.ctx.Err().Error()})
+	}
+}
+
+// finish notes that a job of root completed. The last one to complete
+// determines how long the root took.
+func (w *walker) finish(root *Root) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.reports[root].Duration = time.Since(w.start)
+}
+
+func (w *walker) setDir(path string, state DirState) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.dirs[path] = state
+}
+
+func (w *walker) forgetDir(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.dirs, path)
+}
+
+// cutShort forgets the directories of j that enclose path, where its walk
+// stopped: their subdirectories were only partly recorded, so the next
+// rescan must read them again rather than replay them
+func (w *walker) cutShort(j job, path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for dir := path; dir != j.path; {
+		dir = filepath.Dir(dir)
+		delete(w.dirs, dir)
+	}
+}
+
+// addSubdir records name as a subdirectory of parent. Ignored directories are
+// recorded too, so changing the ignore rules does not require a full rescan.
+func (w *walker) addSubdir(parent, name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state := w.dirs[parent]
+	state.Subdirs = append(state.Subdirs, name)
+	w.dirs[parent] = state
+}
@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// pollInterval is how often the polling watcher rescans. Rescans are
+	// incremental, so only directories that changed are read again.
+	pollInterval = 5 * time.Second
+
+	// settleDelay lets a burst of filesystem events, like a git clone,
+	// finish before rescanning
+	settleDelay = 300 * time.Millisecond
+)
+
+type EventKind string
+
+const (
+	EventAdded   EventKind = "added" // a new repository, or one whose kind changed
+	EventRemoved EventKind = "removed"
+)
+
+// Event reports a repository appearing or disappearing below a watched root
+type Event struct {
+	Kind EventKind
+	Repo Repository
+}
+
+// Watcher keeps the repositories below a set of roots up to date. On Linux
+// it rescans when inotify reports a change to a scanned directory; elsewhere,
+// or when inotify is unavailable, it rescans periodically. Either way the
+// rescan is incremental and applies the same options and ignore rules as
+// the initial scan.
+type Watcher struct {
+	roots  []Root
+	events chan Event
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+
+	mu    sync.Mutex
+	index *Index
+}
+
+func NewWatcher(roots []Root) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		roots:  roots,
+		events: make(chan Event, 64),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start begins watching from base, an index of the watcher's roots. Changes
+// relative to base are reported on Events.
+func (w *Watcher) Start(base *Index) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Closed already, e.g. the TUI quit before its rescan finished
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	w.index = base
+	w.done.Add(1)
+	go func() {
+		defer w.done.Done()
+		w.watch()
+	}()
+}
+
+// Events delivers changes until the watcher is closed
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Index returns the index as of the latest rescan
+func (w *Watcher) Index() *Index {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.index
+}
+
+// Close stops watching and closes Events
+func (w *Watcher) Close() {
+	w.mu.Lock()
+	w.cancel()
+	w.mu.Unlock()
+
+	w.done.Wait()
+	close(w.events)
+}
+
+// refresh rescans the roots and reports what changed since the last rescan
+func (w *Watcher) refresh() {
+	prev := w.Index()
+	fresh, err := Rescan(w.ctx, w.roots, prev)
+	if err != nil || w.ctx.Err() != nil {
+		// A cancelled rescan is incomplete, don't mistake it for removals
+		return
+	}
+
+	w.mu.Lock()
+	w.index = fresh
+	w.mu.Unlock()
+
+	for _, event := range diffRepositories(prev.Repositories, fresh.Repositories) {
+		select {
+		case w.events <- event:
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// poll rescans every pollInterval until the watcher is closed
+func (w *Watcher) poll() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.refresh()
+		}
+	}
+}
+
+func diffRepositories(before, after []Repository) []Event {
+	old := make(map[string]Repository, len(before))
+	for _, repo := range before {
+		old[repo.Path] = repo
+	}
+
+	var events []Event
+	for _, repo := range after {
+		if prev, ok := old[repo.Path]; !ok || prev != repo {
+			events = append(events, Event{Kind: EventAdded, Repo: repo})
+		}
+		delete(old, repo.Path)
+	}
+	for _, repo := range before {
+		if _, ok := old[repo.Path]; ok {
+			events = append(events, Event{Kind: EventRemoved, Repo: repo})
+		}
+	}
+	return events
+}
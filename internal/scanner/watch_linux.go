@@ -0,0 +1,130 @@
+//go:build linux
+
+package scanner
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// inotifyMask selects the events that change which entries a directory has
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+
+// inotify watches every directory of the current index. Events only signal
+// that something changed; the rescan that follows works out what.
+type inotify struct {
+	fd   int
+	file *os.File
+	wds  map[string]int // watched path to watch descriptor
+}
+
+func (w *Watcher) watch() {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		w.poll()
+		return
+	}
+
+	// A non-blocking descriptor goes through the runtime poller, so closing
+	// the file interrupts a pending Read
+	n := &inotify{fd: fd, file: os.NewFile(uintptr(fd), "inotify"), wds: make(map[string]int)}
+	err = w.notify(n)
+	n.file.Close()
+
+	// Out of watches, typically: fall back to polling
+	if err != nil {
+		w.poll()
+	}
+}
+
+// notify rescans whenever inotify reports a change, until the watcher is
+// closed or a directory can't be watched
+func (w *Watcher) notify(n *inotify) error {
+	if err := n.sync(w.Index()); err != nil {
+		return err
+	}
+
+	changed := make(chan struct{}, 1)
+	go n.read(changed)
+
+	// Catch up on changes made between the base scan and the watches
+	w.refresh()
+	if err := n.sync(w.Index()); err != nil {
+		return err
+	}
+
+	settle := time.NewTimer(settleDelay)
+	settle.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil
+		case <-changed:
+			settle.Reset(settleDelay)
+		case <-settle.C:
+			w.refresh()
+			if err := n.sync(w.Index()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// read signals changed whenever events arrive, until the descriptor is
+// closed
+func (n *inotify) read(changed chan<- struct{}) {
+	buf := make([]byte, 64*1024)
+	for {
+		if _, err := n.file.Read(buf); err != nil {
+			return
+		}
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// sync watches every directory in index and stops watching the rest
+func (n *inotify) sync(index *Index) error {
+	for path := range index.Dirs {
+		if _, ok := n.wds[path]; ok {
+			continue
+		}
+
+		wd, err := syscall.InotifyAddWatch(n.fd, path, inotifyMask)
+		if err != nil {
+			if errors.Is(err, syscall.ENOSPC) {
+				return err
+			}
+			continue // gone or unreadable since the rescan
+		}
+		n.wds[path] = wd
+	}
+
+	for path, wd := range n.wds {
+		if _, ok := index.Dirs[path]; ok {
+			continue
+		}
+		delete(n.wds, path)
+
+		// The same directory reached through another path shares its wd
+		if !n.watching(wd) {
+			syscall.InotifyRmWatch(n.fd, uint32(wd))
+		}
+	}
+	return nil
+}
+
+func (n *inotify) watching(wd int) bool {
+	for _, other := range n.wds {
+		if other == wd {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package scanner
+
+func (w *Watcher) watch() {
+	w.poll()
+}
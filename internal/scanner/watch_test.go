@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func nextEvent(t *testing.T, w *Watcher) Event {
+	t.Helper()
+	select {
+	case event := <-w.Events():
+		return event
+	case <-time.After(pollInterval + 5*time.Second):
+		t.Fatal("timed out waiting for a watch event")
+		return Event{}
+	}
+}
+
+func TestWatcher_ReportsAddedAndRemovedRepositories(t *testing.T) {
+	tmpDir := t.TempDir()
+	makeRepo(t, filepath.Join(tmpDir, "existing"))
+
+	roots := []Root{{Path: tmpDir}}
+	base, err := Rescan(context.Background(), roots, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	w := NewWatcher(roots)
+	w.Start(base)
+	defer w.Close()
+
+	cloned := filepath.Join(tmpDir, "org", "cloned")
+	makeRepo(t, cloned)
+
+	event := nextEvent(t, w)
+	if event.Kind != EventAdded || event.Repo.Path != cloned {
+		t.Fatalf("expected %s to be added, got %+v", cloned, event)
+	}
+
+	os.RemoveAll(cloned)
+
+	event = nextEvent(t, w)
+	if event.Kind != EventRemoved || event.Repo.Path != cloned {
+		t.Fatalf("expected %s to be removed, got %+v", cloned, event)
+	}
+}
+
+func TestWatcher_RespectsIgnoreRules(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	roots := []Root{{Path: tmpDir, Rules: []IgnoreRules{{Ignore: []string{"build"}}}}}
+	base, err := Rescan(context.Background(), roots, nil)
+	if err != nil {
+		t.Fatalf("Rescan() returned error: %v", err)
+	}
+
+	w := NewWatcher(roots)
+	w.index = base
+
+	makeRepo(t, filepath.Join(tmpDir, "node_modules", "dep"))
+	makeRepo(t, filepath.Join(tmpDir, "build", "out"))
+	makeRepo(t, filepath.Join(tmpDir, "app"))
+
+	w.refresh()
+	w.cancel()
+	close(w.events)
+
+	var added []string
+	for event := range w.Events() {
+		added = append(added, event.Repo.Name)
+	}
+	if len(added) != 1 || added[0] != "app" {
+		t.Errorf("expected only app to be added, got %v", added)
+	}
+}
+
+func TestDiffRepositories(t *testing.T) {
+	before := []Repository{{Name: "a", Path: "/a"}, {Name: "b", Path: "/b"}}
+	after := []Repository{{Name: "b", Path: "/b", Kind: KindWorktree}, {Name: "c", Path: "/c"}}
+
+	events := diffRepositories(before, after)
+
+	want := []Event{
+		{Kind: EventAdded, Repo: after[0]},
+		{Kind: EventAdded, Repo: after[1]},
+		{Kind: EventRemoved, Repo: before[0]},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d: expected %+v, got %+v", i, want[i], events[i])
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/tiagokriok/Git-Fuzzy/internal/metadata"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+// enrichWorkers bounds how many repositories are measured at once, since
+// each one means walking its whole tree
+const enrichWorkers = 4
+
+// enricher collects repository metadata in the background. It is shared by
+// pointer so the value-receiver parts of Model can schedule work too.
+type enricher struct {
+	cache *metadata.Cache
+	slots chan struct{}
+
+	mu   sync.Mutex
+	seen map[string]bool // checked or collected during this session
+}
+
+type metadataMsg struct {
+	path string
+}
+
+func newEnricher(cache *metadata.Cache) *enricher {
+	return &enricher{
+		cache: cache,
+		slots: make(chan struct{}, enrichWorkers),
+		seen:  make(map[string]bool),
+	}
+}
+
+// enrich collects metadata for the repos whose cached metadata is missing or
+// outdated. Each repository is looked at once per session.
+func (e *enricher) enrich(repos []scanner.Repository) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, repo := range repos {
+		if !e.claim(repo.Path) {
+			continue
+		}
+		if _, fresh, _ := e.cache.Get(repo); fresh {
+			continue
+		}
+
+		cmds = append(cmds, func() tea.Msg {
+			e.slots <- struct{}{}
+			defer func() { <-e.slots }()
+
+			e.cache.Refresh(repo)
+			return metadataMsg{path: repo.Path}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func (e *enricher) claim(path string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.seen[path] {
+		return false
+	}
+	e.seen[path] = true
+	return true
+}
+
+func (e *enricher) get(repo scanner.Repository) (metadata.Metadata, bool) {
+	return e.cache.Peek(repo.Path)
+}
+
+// sortMode is the order of the repository list, cycled with ctrl+s
+type sortMode int
+
+const (
+	sortRecent     sortMode = iota // by frecency, combined with match quality while searching
+	sortLastCommit                 // most recently committed to first
+	sortSize                       // largest first
+	sortName
+)
+
+var sortModeNames = []string{"recent", "last commit", "size", "name"}
+
+func (s sortMode) String() string {
+	return sortModeNames[s]
+}
+
+func (s sortMode) next() sortMode {
+	return (s + 1) % sortMode(len(sortModeNames))
+}
+
+// needsMetadata reports whether sorting needs the metadata of every
+// repository, not just the visible ones
+func (s sortMode) needsMetadata() bool {
+	return s == sortLastCommit || s == sortSize
+}
+
+// sortRepositories orders repos in place. Repositories without metadata go
+// last, in their original order.
+func (m Model) sortRepositories(repos []scanner.Repository) {
+	switch m.sortMode {
+	case sortName:
+		slices.SortStableFunc(repos, func(a, b scanner.Repository) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	case sortLastCommit:
+		m.sortByMetadata(repos, func(data metadata.Metadata) int64 {
+			return data.LastCommit.Unix()
+		})
+	case sortSize:
+		m.sortByMetadata(repos, func(data metadata.Metadata) int64 {
+			return data.Size
+		})
+	}
+}
+
+// sortByMetadata sorts repos by key, largest first
+func (m Model) sortByMetadata(repos []scanner.Repository, key func(metadata.Metadata) int64) {
+	keys := make(map[string]int64, len(repos))
+	for _, repo := range repos {
+		if data, ok := m.enricher.get(repo); ok {
+			keys[repo.Path] = key(data)
+		}
+	}
+
+	slices.SortStableFunc(repos, func(a, b scanner.Repository) int {
+		ka, oka := keys[a.Path]
+		kb, okb := keys[b.Path]
+		switch {
+		case oka != okb:
+			if oka {
+				return -1
+			}
+			return 1
+		case ka > kb:
+			return -1
+		case ka < kb:
+			return 1
+		}
+		return 0
+	})
+}
+
+// matchesLanguage reports whether the dominant language of repo starts with
+// language, ignoring case
+func (m Model) matchesLanguage(repo scanner.Repository, language string) bool {
+	data, ok := m.enricher.get(repo)
+	if !ok {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(data.Language), strings.ToLower(language))
+}
+
+// enrichCmd collects the metadata the current view needs: every repository
+// when sorting or filtering by it, otherwise those around the selection
+func (m Model) enrichCmd() tea.Cmd {
+	if parseQuery(m.search.Value()).needsMetadata() || m.sortMode.needsMetadata() {
+		return m.enricher.enrich(m.repositories)
+	}
+
+	return m.enricher.enrich(m.aroundSelection())
+}
+
+// aroundSelection returns the filtered repositories that are visible or
+// become visible by scrolling a page either way
+func (m Model) aroundSelection() []scanner.Repository {
+	start := max(m.selectedIdx-maxHeight+1, 0)
+	end := min(m.selectedIdx+maxHeight, len(m.filtered))
+	if start >= end {
+		return nil
+	}
+	return m.filtered[start:end]
+}
+
+// metadataSummary describes repo in one line, e.g. "🕒 3d ago · Go · 12 MB"
+func (m Model) metadataSummary(repo scanner.Repository) string {
+	data, ok := m.enricher.get(repo)
+	if !ok {
+		return ""
+	}
+
+	var parts []string
+	if !data.LastCommit.IsZero() {
+		parts = append(parts, "🕒 "+metadata.FormatAge(data.LastCommit))
+	}
+	if data.Language != "" {
+		parts = append(parts, data.Language)
+	}
+	parts = append(parts, formatSize(data.Size))
+	return strings.Join(parts, " · ")
+}
+
+// sortKeyLabel shows the value the list is sorted by next to each row
+func (m Model) sortKeyLabel(repo scanner.Repository) string {
+	data, ok := m.enricher.get(repo)
+	if !ok {
+		return ""
+	}
+
+	switch m.sortMode {
+	case sortLastCommit:
+		if !data.LastCommit.IsZero() {
+			return metadata.FormatAge(data.LastCommit)
+		}
+	case sortSize:
+		return formatSize(data.Size)
+	}
+	return ""
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value := float64(bytes)
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		value /= unit
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%.1f TB", value/unit)
+}
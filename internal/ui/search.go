@@ -0,0 +1,359 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
+)
+
+// nameBonus is added to the score of a match in a repository's name, so it
+// ranks above a similar match in its path or remote
+const nameBonus = 15
+
+// fieldPrefixes restrict a search term to one field, e.g. "remote:acme"
+var fieldPrefixes = []string{"path", "remote", "branch"}
+
+// searchQuery is the parsed search input. Like fzf's extended search, a
+// repository must match every term.
+type searchQuery struct {
+	terms    []searchTerm
+	language string        // from lang:
+	states   []stateFilter // from is:
+}
+
+// termKind is how a search term matches, following fzf's syntax
+type termKind int
+
+const (
+	termFuzzy  termKind = iota // foo
+	termExact                  // 'foo: contains foo
+	termPrefix                 // ^foo
+	termSuffix                 // foo$
+	termEqual                  // ^foo$
+)
+
+// searchTerm is one space-separated word of the search input
+type searchTerm struct {
+	text   string
+	kind   termKind
+	negate bool   // !foo: must not match, exactly unless stated otherwise
+	field  string // field the term is restricted to, e.g. by "path:", or "" for the configured ones
+}
+
+type remotesMsg struct {
+	remotes  map[string]string
+	branches map[string]string
+}
+
+// parseQuery splits the search input into terms, a lang: filter and is:
+// filters, e.g. "lang:go is:dirty ^svc- !legacy remote:acme". Terms may be
+// restricted to a field with a prefix, before or after a "!": "!path:old" and
+// "path:!old" both exclude repositories whose path contains "old".
+func parseQuery(input string) searchQuery {
+	var q searchQuery
+	for _, word := range strings.Fields(input) {
+		if value, ok := strings.CutPrefix(word, "lang:"); ok {
+			q.language = value
+			continue
+		}
+
+		rest, negate := strings.CutPrefix(word, "!")
+		if state, ok := strings.CutPrefix(rest, "is:"); ok {
+			// Unknown states, e.g. while typing one, filter nothing
+			if slices.Contains(repoStates, state) {
+				q.states = append(q.states, stateFilter{state: state, negate: negate})
+			}
+			continue
+		}
+
+		var field string
+		if name, value, ok := strings.Cut(rest, ":"); ok && slices.Contains(fieldPrefixes, name) {
+			field, word = name, value
+			if negate {
+				word = "!" + word
+			}
+		}
+
+		if term, ok := parseTerm(word); ok {
+			term.field = field
+			q.terms = append(q.terms, term)
+		}
+	}
+	return q
+}
+
+// parseTerm parses a word of fzf's extended search syntax. Words left
+// without text, like a lone "!" while typing, match everything and are
+// dropped.
+func parseTerm(word string) (searchTerm, bool) {
+	var t searchTerm
+	word, t.negate = strings.CutPrefix(word, "!")
+
+	var quoted, prefix, suffix bool
+	if word, quoted = strings.CutPrefix(word, "'"); !quoted {
+		word, prefix = strings.CutPrefix(word, "^")
+		word, suffix = strings.CutSuffix(word, "$")
+	}
+
+	switch {
+	case quoted && t.negate:
+		// In a negation the quote turns exact matching back into fuzzy
+		t.kind = termFuzzy
+	case quoted, t.negate && !prefix && !suffix:
+		t.kind = termExact
+	case prefix && suffix:
+		t.kind = termEqual
+	case prefix:
+		t.kind = termPrefix
+	case suffix:
+		t.kind = termSuffix
+	}
+	t.text = word
+	return t, word != ""
+}
+
+// empty reports whether q matches every repository by text, leaving only
+// lang: and is: filters if any
+func (q searchQuery) empty() bool {
+	return len(q.terms) == 0
+}
+
+// needsMetadata reports whether the query filters on collected metadata, so
+// that of every repository is needed. Branches are read with the remotes.
+func (q searchQuery) needsMetadata() bool {
+	return q.language != ""
+}
+
+// needsStatus reports whether the query filters on working copy status, so
+// that of every repository is needed
+func (q searchQuery) needsStatus() bool {
+	return len(q.states) > 0
+}
+
+// matchText matches t against text, returning the score and the byte offsets
+// of the matched characters, regardless of negation
+func (t searchTerm) matchText(text string) (int, []int, bool) {
+	if t.kind == termFuzzy {
+		return fuzzyMatch(t.text, text)
+	}
+
+	start, ok := t.find(text)
+	if !ok {
+		return 0, nil, false
+	}
+
+	// Score the match like the same characters matched fuzzily
+	end := start + len(t.text)
+	score, _, _ := fuzzyMatch(t.text, text[start:end])
+	var offsets []int
+	for i := range text[start:end] {
+		offsets = append(offsets, start+i)
+	}
+	return score, offsets, true
+}
+
+// find returns where t's text occurs in text as its kind requires. Like
+// fzf's smart case, it ignores case unless the term has upper case letters.
+func (t searchTerm) find(text string) (int, bool) {
+	n := len(t.text)
+	if n > len(text) {
+		return 0, false
+	}
+
+	equal := func(s string) bool { return s == t.text }
+	if !strings.ContainsFunc(t.text, unicode.IsUpper) {
+		equal = func(s string) bool { return strings.EqualFold(s, t.text) }
+	}
+
+	switch t.kind {
+	case termPrefix:
+		return 0, equal(text[:n])
+	case termSuffix:
+		return len(text) - n, equal(text[len(text)-n:])
+	case termEqual:
+		return 0, len(text) == n && equal(text)
+	}
+	for i := range text {
+		if i+n <= len(text) && equal(text[i:i+n]) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// highlight is where a repository's row matched the search, as byte offsets
+// into its name and its path relative to the search path
+type highlight struct {
+	name []int
+	path []int
+}
+
+// add records the positions matched in field, if the row shows it
+func (h *highlight) add(field string, indexes []int) {
+	switch field {
+	case "name":
+		h.name = append(h.name, indexes...)
+	case "path":
+		h.path = append(h.path, indexes...)
+	}
+}
+
+// match reports whether repo matches q, how well and where. Each term must
+// match at least one of its fields, the configured ones unless restricted,
+// and the best of them counts; negated terms must match none.
+func (m Model) match(repo scanner.Repository, q searchQuery) (int, highlight, bool) {
+	score := 0
+	var h highlight
+
+	for _, term := range q.terms {
+		fields := m.config.SearchInFields()
+		if term.field != "" {
+			fields = []string{term.field}
+		}
+
+		best, found := 0, false
+		var bestField string
+		var bestIndexes []int
+		for _, field := range fields {
+			s, indexes, ok := term.matchText(m.fieldValue(repo, field))
+			if !ok {
+				continue
+			}
+			if field == "name" {
+				s += nameBonus
+			}
+			if !found || s > best {
+				best, found = s, true
+				bestField, bestIndexes = field, indexes
+			}
+		}
+
+		if found == term.negate {
+			return 0, highlight{}, false
+		}
+		if !term.negate {
+			score += best
+			h.add(bestField, bestIndexes)
+		}
+	}
+	return score, h, true
+}
+
+// fuzzyMatch matches pattern against text, returning the score and the byte
+// offsets of the matched characters
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	if text == "" {
+		return 0, nil, false
+	}
+	matches := fuzzy.Find(pattern, []string{text})
+	if len(matches) == 0 {
+		return 0, nil, false
+	}
+	return matches[0].Score, matches[0].MatchedIndexes, true
+}
+
+// renderHighlighted renders text with base, and the characters starting at
+// the given byte offsets with matched, one run at a time so neither style
+// resets the other
+func renderHighlighted(text string, offsets []int, base, matched lipgloss.Style) string {
+	if len(offsets) == 0 {
+		return base.Render(text)
+	}
+
+	var b strings.Builder
+	runStart, runMatched := 0, false
+	for i := range text {
+		isMatched := slices.Contains(offsets, i)
+		if i > 0 && isMatched != runMatched {
+			b.WriteString(styleFor(runMatched, base, matched).Render(text[runStart:i]))
+			runStart = i
+		}
+		runMatched = isMatched
+	}
+	b.WriteString(styleFor(runMatched, base, matched).Render(text[runStart:]))
+	return b.String()
+}
+
+func styleFor(isMatched bool, base, matched lipgloss.Style) lipgloss.Style {
+	if isMatched {
+		return matched
+	}
+	return base
+}
+
+// fieldValue returns the text of a search field of repo, or "" if unknown
+func (m Model) fieldValue(repo scanner.Repository, field string) string {
+	switch field {
+	case "name":
+		return repo.Name
+	case "path":
+		return m.relativePath(repo)
+	case "remote":
+		return m.remotes[repo.Path]
+	case "branch":
+		if branch := m.branches[repo.Path]; branch != "" {
+			return branch
+		}
+		// Jujutsu and Fossil branches are only known once collected
+		if data, ok := m.enricher.get(repo); ok {
+			return data.Branch
+		}
+	}
+	return ""
+}
+
+// relativePath returns the path of repo below the search path it was found
+// in, e.g. "acme/api"
+func (m Model) relativePath(repo scanner.Repository) string {
+	best := ""
+	for _, searchPath := range m.config.Paths() {
+		rel, err := filepath.Rel(searchPath, repo.Path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if best == "" || len(rel) < len(best) {
+			best = rel
+		}
+	}
+
+	if best == "" {
+		return formatRepoPath(repo.Path)
+	}
+	return filepath.ToSlash(best)
+}
+
+// loadRemotes reads the remote and the checked-out branch of every
+// repository in repos not looked at yet, in the background
+func (m Model) loadRemotes(repos []scanner.Repository) tea.Cmd {
+	var pending []scanner.Repository
+	for _, repo := range repos {
+		if _, ok := m.remotes[repo.Path]; !ok {
+			m.remotes[repo.Path] = "" // until read
+			pending = append(pending, repo)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		remotes := make(map[string]string, len(pending))
+		branches := make(map[string]string, len(pending))
+		for _, repo := range pending {
+			if url := vcs.ReadRemoteURL(repo); url != "" {
+				remotes[repo.Path] = vcs.OwnerRepo(url)
+			}
+			if branch := vcs.ReadHeadBranch(repo); branch != "" {
+				branches[repo.Path] = branch
+			}
+		}
+		return remotesMsg{remotes: remotes, branches: branches}
+	}
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/config"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func TestParseQuery_Terms(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []searchTerm
+	}{
+		{"api", []searchTerm{{text: "api", kind: termFuzzy}}},
+		{"'api", []searchTerm{{text: "api", kind: termExact}}},
+		{"^api", []searchTerm{{text: "api", kind: termPrefix}}},
+		{"api$", []searchTerm{{text: "api", kind: termSuffix}}},
+		{"^api$", []searchTerm{{text: "api", kind: termEqual}}},
+		{"!api", []searchTerm{{text: "api", kind: termExact, negate: true}}},
+		{"!'api", []searchTerm{{text: "api", kind: termFuzzy, negate: true}}},
+		{"!^api", []searchTerm{{text: "api", kind: termPrefix, negate: true}}},
+		{"path:!old", []searchTerm{{text: "old", kind: termExact, negate: true, field: "path"}}},
+		{"!path:old", []searchTerm{{text: "old", kind: termExact, negate: true, field: "path"}}},
+		{"remote:^acme", []searchTerm{{text: "acme", kind: termPrefix, field: "remote"}}},
+		{"other:api", []searchTerm{{text: "other:api", kind: termFuzzy}}},
+		{"! ' ^", nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseQuery(tt.input).terms; !slices.Equal(got, tt.want) {
+			t.Errorf("%q: expected terms %+v, got %+v", tt.input, tt.want, got)
+		}
+	}
+}
+
+func TestParseQuery_Filters(t *testing.T) {
+	q := parseQuery("lang:go is:dirty !is:stash is:dirt api")
+
+	if q.language != "go" {
+		t.Errorf("expected language go, got %q", q.language)
+	}
+	want := []stateFilter{{state: "dirty"}, {state: "stash", negate: true}}
+	if !slices.Equal(q.states, want) {
+		t.Errorf("expected states %+v, got %+v", want, q.states)
+	}
+	if len(q.terms) != 1 || q.terms[0].text != "api" {
+		t.Errorf("expected only the api term, got %+v", q.terms)
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	tests := []struct {
+		term    string
+		text    string
+		ok      bool
+		offsets []int
+	}{
+		{"mapi", "my-api", true, []int{0, 3, 4, 5}},
+		{"'api", "my-api-server", true, []int{3, 4, 5}},
+		{"'api", "a-p-i", false, nil},
+		{"^my", "my-api", true, []int{0, 1}},
+		{"^api", "my-api", false, nil},
+		{"api$", "my-api", true, []int{3, 4, 5}},
+		{"api$", "api-server", false, nil},
+		{"^api$", "api", true, []int{0, 1, 2}},
+		{"^api$", "api2", false, nil},
+
+		// Smart case: lower case terms ignore case, others don't
+		{"'api", "MY-API", true, []int{3, 4, 5}},
+		{"'API", "my-api", false, nil},
+		{"'API", "MY-API", true, []int{3, 4, 5}},
+
+		// Negation is left to the caller
+		{"!api", "my-api", true, []int{3, 4, 5}},
+		{"!api", "a-p-i", false, nil},
+		{"!'api", "a-p-i", true, []int{0, 2, 4}},
+
+		// Terms longer than the text
+		{"'api-server", "api", false, nil},
+		{"^api-server", "api", false, nil},
+		{"api-server$", "api", false, nil},
+	}
+
+	for _, tt := range tests {
+		term, _ := parseTerm(tt.term)
+		_, offsets, ok := term.matchText(tt.text)
+		if ok != tt.ok || !slices.Equal(offsets, tt.offsets) {
+			t.Errorf("%q in %q: expected %v at %v, got %v at %v", tt.term, tt.text, tt.ok, tt.offsets, ok, offsets)
+		}
+	}
+}
+
+func searchModel(paths ...string) Model {
+	cfg := &config.Config{}
+	for _, path := range paths {
+		cfg.SearchPaths = append(cfg.SearchPaths, config.SearchPath{Path: path})
+	}
+	return Model{config: cfg, remotes: make(map[string]string), branches: make(map[string]string)}
+}
+
+func TestMatch_NameRanksAbovePath(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	m := searchModel(src)
+	named := scanner.Repository{Name: "api", Path: filepath.Join(src, "api")}
+	nested := scanner.Repository{Name: "legacy", Path: filepath.Join(src, "api", "legacy")}
+	q := parseQuery("api")
+
+	namedScore, namedHighlight, ok := m.match(named, q)
+	if !ok {
+		t.Fatalf("expected %s to match", named.Path)
+	}
+	nestedScore, nestedHighlight, ok := m.match(nested, q)
+	if !ok {
+		t.Fatalf("expected %s to match", nested.Path)
+	}
+
+	if namedScore <= nestedScore {
+		t.Errorf("expected the name match to score above the path match, got %d and %d", namedScore, nestedScore)
+	}
+	if !slices.Equal(namedHighlight.name, []int{0, 1, 2}) || namedHighlight.path != nil {
+		t.Errorf("expected the name to be highlighted, got %+v", namedHighlight)
+	}
+	if !slices.Equal(nestedHighlight.path, []int{0, 1, 2}) || nestedHighlight.name != nil {
+		t.Errorf("expected the path to be highlighted, got %+v", nestedHighlight)
+	}
+
+	if _, _, ok := m.match(nested, parseQuery("!path:api")); ok {
+		t.Errorf("expected !path:api to exclude %s", nested.Path)
+	}
+}
+
+func TestMatch_Branch(t *testing.T) {
+	m := searchModel()
+	repo := scanner.Repository{Name: "api", Path: filepath.Join(t.TempDir(), "api")}
+	m.branches[repo.Path] = "feature/login"
+
+	if _, _, ok := m.match(repo, parseQuery("branch:^feature")); !ok {
+		t.Errorf("expected branch:^feature to match")
+	}
+	if _, _, ok := m.match(repo, parseQuery("branch:main")); ok {
+		t.Errorf("expected branch:main not to match")
+	}
+	if parseQuery("branch:main").needsMetadata() {
+		t.Errorf("expected branch: not to need collected metadata")
+	}
+}
+
+func TestRelativePath_ShortestAcrossSearchPaths(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+	work := filepath.Join(src, "work")
+	m := searchModel(src, work)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(work, "acme", "api"), "acme/api"},
+		{filepath.Join(src, "workshop", "api"), "workshop/api"},
+		{filepath.Join(src, "api"), "api"},
+	}
+
+	for _, tt := range tests {
+		if got := m.relativePath(scanner.Repository{Path: tt.path}); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
@@ -79,14 +79,14 @@ func (m SetupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				homeDir, _ := os.UserHomeDir()
-				var searchPaths []string
+				var searchPaths []config.SearchPath
 				for path := range strings.SplitSeq(m.paths.Value(), ",") {
 					path = strings.TrimSpace(path)
 					if path != "" {
 						if strings.HasPrefix(path, "~") {
 							path = strings.Replace(path, "~", homeDir, 1)
 						}
-						searchPaths = append(searchPaths, path)
+						searchPaths = append(searchPaths, config.SearchPath{Path: path})
 					}
 				}
 
@@ -0,0 +1,274 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
+)
+
+// statusWorkers is how many repositories' status is computed at once, since
+// each one runs several git commands
+const statusWorkers = 4
+
+// statusTTL is how long a status read is trusted while the repository's
+// stamp is unchanged. Edits to working files change no stamp, so it bounds how
+// long they go unnoticed.
+const statusTTL = 30 * time.Second
+
+// repoStates are the working copy states the search filters on with is:,
+// toggled with alt+1 to alt+5 in this order
+var repoStates = []string{"dirty", "ahead", "behind", "stash", "detached"}
+
+// stateFilter is an is: term of the search, e.g. "is:dirty" or "!is:stash"
+type stateFilter struct {
+	state  string
+	negate bool
+}
+
+// statusEntry is the cached working copy status of a repository
+type statusEntry struct {
+	data    *git.StatusData // nil if the status couldn't be read
+	stamp   time.Time       // vcs.StatusStamp of the repository when data was read
+	readAt  time.Time
+	loaded  bool
+	loading bool
+}
+
+type statusMsg struct {
+	path      string
+	data      *git.StatusData
+	err       error
+	stamp     time.Time
+	unchanged bool // the stamp still matched, so data wasn't read again
+}
+
+// hasState reports whether a working copy with status data is in state
+func hasState(data *git.StatusData, state string) bool {
+	switch state {
+	case "dirty":
+		return len(data.Files) > 0
+	case "ahead":
+		return data.AheadCount > 0
+	case "behind":
+		return data.BehindCount > 0
+	case "stash":
+		return data.StashCount > 0
+	case "detached":
+		return data.Detached
+	}
+	return false
+}
+
+// matchesStates reports whether repo satisfies every filter. Until its status
+// is known it satisfies none.
+func (m Model) matchesStates(repo scanner.Repository, filters []stateFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	data := m.statuses[repo.Path].data
+	if data == nil {
+		return false
+	}
+	for _, f := range filters {
+		if hasState(data, f.state) == f.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// queueStatuses brings the status of the repositories around the selection
+// up to date, for their badges, and loads that of every other repository
+// once when the search filters on it
+func (m Model) queueStatuses() {
+	m.loadStatuses(m.aroundSelection(), true)
+	if parseQuery(m.search.Value()).needsStatus() {
+		m.loadStatuses(m.repositories, false)
+	}
+}
+
+// loadStatuses queues reading the status of repos in the background.
+// Repositories loaded before are only checked again if revalidate is set, and
+// only read again if their stamp changed since or the read is older than
+// statusTTL; those go first, as they are around the selection. Bare
+// repositories have no status.
+func (m Model) loadStatuses(repos []scanner.Repository, revalidate bool) {
+	var jobs []statusJob
+	for _, repo := range repos {
+		entry := m.statuses[repo.Path]
+		if repo.Kind == scanner.KindBare || entry.loading || (entry.loaded && !revalidate) {
+			continue
+		}
+		entry.loading = true
+		m.statuses[repo.Path] = entry
+		jobs = append(jobs, statusJob{repo: repo, previous: entry})
+	}
+	m.statusQueue.push(jobs, revalidate)
+}
+
+// statusJob is a repository whose status is wanted, with what was cached of
+// it when it was queued
+type statusJob struct {
+	repo     scanner.Repository
+	previous statusEntry
+}
+
+// read reads the status of the job's repository unless the cached one is
+// still current
+func (j statusJob) read() statusMsg {
+	// Stamp first: a change while reading makes the next check read again
+	stamp := vcs.StatusStamp(j.repo)
+	if j.previous.loaded && stamp.Equal(j.previous.stamp) && time.Since(j.previous.readAt) < statusTTL {
+		return statusMsg{path: j.repo.Path, unchanged: true}
+	}
+
+	data, err := vcs.GetDetailedStatus(j.repo)
+	if err != nil {
+		return statusMsg{path: j.repo.Path, err: err, stamp: stamp}
+	}
+	return statusMsg{path: j.repo.Path, data: data, stamp: stamp}
+}
+
+// statusQueue hands queued status reads to statusWorkers workers, which run
+// until the TUI exits, and their results to waitForStatus. It is shared by
+// pointer so the value-receiver parts of Model can queue work too.
+type statusQueue struct {
+	mu      sync.Mutex
+	jobs    []statusJob
+	ready   chan struct{} // signaled when jobs were queued
+	results chan statusMsg
+}
+
+func newStatusQueue(quit <-chan struct{}) *statusQueue {
+	q := &statusQueue{
+		ready:   make(chan struct{}, 1),
+		results: make(chan statusMsg),
+	}
+	for range statusWorkers {
+		go q.work(quit)
+	}
+	return q
+}
+
+// push queues jobs, ahead of those already queued if urgent
+func (q *statusQueue) push(jobs []statusJob, urgent bool) {
+	if len(jobs) == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	if urgent {
+		q.jobs = slices.Concat(jobs, q.jobs)
+	} else {
+		q.jobs = append(q.jobs, jobs...)
+	}
+	q.mu.Unlock()
+	q.signal()
+}
+
+// pop takes the next job, if any, and wakes another worker for the rest
+func (q *statusQueue) pop() (statusJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.jobs) == 0 {
+		return statusJob{}, false
+	}
+	job := q.jobs[0]
+	q.jobs = q.jobs[1:]
+	if len(q.jobs) > 0 {
+		q.signal()
+	}
+	return job, true
+}
+
+func (q *statusQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default: // a worker is about to look anyway
+	}
+}
+
+func (q *statusQueue) work(quit <-chan struct{}) {
+	for {
+		job, ok := q.pop()
+		if !ok {
+			select {
+			case <-q.ready:
+				continue
+			case <-quit:
+				return
+			}
+		}
+
+		select {
+		case q.results <- job.read():
+		case <-quit:
+			return
+		}
+	}
+}
+
+// waitForStatus waits for the next status read. The model asks again after
+// each one.
+func waitForStatus(q *statusQueue) tea.Cmd {
+	return func() tea.Msg {
+		return <-q.results
+	}
+}
+
+// setStatus caches the status of the repository at path, read at stamp
+func (m Model) setStatus(path string, data *git.StatusData, stamp time.Time) {
+	m.statuses[path] = statusEntry{data: data, stamp: stamp, readAt: time.Now(), loaded: true}
+}
+
+// statusBadges summarizes the cached status of repo for its row, e.g.
+// "main ● ↑2 ↓1", or returns "" until it is loaded
+func (m Model) statusBadges(repo scanner.Repository) string {
+	data := m.statuses[repo.Path].data
+	if data == nil {
+		return ""
+	}
+
+	branch := data.CurrentBranch
+	if data.Detached {
+		branch = "detached"
+	}
+	badges := []string{lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Render(branch)}
+
+	if len(data.Files) > 0 {
+		badges = append(badges, lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Render("●"))
+	}
+	arrowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+	if data.AheadCount > 0 {
+		badges = append(badges, arrowStyle.Render(fmt.Sprintf("↑%d", data.AheadCount)))
+	}
+	if data.BehindCount > 0 {
+		badges = append(badges, arrowStyle.Render(fmt.Sprintf("↓%d", data.BehindCount)))
+	}
+	return strings.Join(badges, " ")
+}
+
+// toggleState adds an is: filter for state to the search, or removes it,
+// negated or not, if there is one
+func (m *Model) toggleState(state string) tea.Cmd {
+	token := "is:" + state
+	words := strings.Fields(m.search.Value())
+
+	kept := slices.DeleteFunc(slices.Clone(words), func(word string) bool {
+		return word == token || word == "!"+token
+	})
+	if len(kept) == len(words) {
+		kept = append(kept, token)
+	}
+	return m.setQuery(strings.Join(kept, " "))
+}
@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+	"github.com/tiagokriok/Git-Fuzzy/internal/metadata"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
+)
+
+// statusModel returns a model listing repos, with the status of each
+// repository in statuses already loaded
+func statusModel(t *testing.T, repos []scanner.Repository, statuses map[string]*git.StatusData) Model {
+	quit := make(chan struct{})
+	t.Cleanup(func() { close(quit) })
+
+	m := searchModel()
+	m.repositories = repos
+	m.filtered = repos
+	m.search = textinput.New()
+	m.enricher = newEnricher(metadata.NewCache())
+	m.statuses = make(map[string]statusEntry)
+	m.statusQueue = newStatusQueue(quit)
+	for path, data := range statuses {
+		m.setStatus(path, data, time.Time{})
+	}
+	return m
+}
+
+func TestHasState(t *testing.T) {
+	data := &git.StatusData{
+		Files:      []git.FileStatus{{Status: "M", Filename: "main.go"}},
+		AheadCount: 2,
+		StashCount: 1,
+	}
+
+	tests := map[string]bool{
+		"dirty":    true,
+		"ahead":    true,
+		"behind":   false,
+		"stash":    true,
+		"detached": false,
+		"unknown":  false,
+	}
+	for state, want := range tests {
+		if got := hasState(data, state); got != want {
+			t.Errorf("%s: expected %v, got %v", state, want, got)
+		}
+	}
+}
+
+func TestMatchesStates(t *testing.T) {
+	clean := scanner.Repository{Name: "clean", Path: "/repos/clean"}
+	dirty := scanner.Repository{Name: "dirty", Path: "/repos/dirty"}
+	unread := scanner.Repository{Name: "unread", Path: "/repos/unread"}
+	m := statusModel(t, []scanner.Repository{clean, dirty, unread}, map[string]*git.StatusData{
+		clean.Path: {CurrentBranch: "main"},
+		dirty.Path: {CurrentBranch: "main", Files: []git.FileStatus{{Status: "??", Filename: "notes.txt"}}},
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"clean", "dirty", "unread"}},
+		{"is:dirty", []string{"dirty"}},
+		{"!is:dirty", []string{"clean"}},
+		{"is:dirty is:ahead", nil},
+		{"is:dirt", []string{"clean", "dirty", "unread"}}, // unknown states filter nothing
+	}
+
+	for _, tt := range tests {
+		q := parseQuery(tt.query)
+		var got []string
+		for _, repo := range m.repositories {
+			if m.matchesStates(repo, q.states) {
+				got = append(got, repo.Name)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestToggleState(t *testing.T) {
+	clean := scanner.Repository{Name: "api", Path: "/repos/api"}
+	dirty := scanner.Repository{Name: "api-dirty", Path: "/repos/api-dirty"}
+	m := statusModel(t, []scanner.Repository{clean, dirty}, map[string]*git.StatusData{
+		clean.Path: {CurrentBranch: "main"},
+		dirty.Path: {CurrentBranch: "main", Files: []git.FileStatus{{Status: "M", Filename: "main.go"}}},
+	})
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"api", "api is:dirty"},
+		{"api is:dirty", "api"},
+		{"!is:dirty api", "api"},
+		{"is:stash", "is:stash is:dirty"},
+	}
+
+	for _, tt := range tests {
+		m.search.SetValue(tt.query)
+		m.toggleState("dirty")
+		if got := m.search.Value(); got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+
+	m.setQuery("api")
+	m.toggleState("dirty")
+	if len(m.filtered) != 1 || m.filtered[0].Path != dirty.Path {
+		t.Errorf("expected only %s to be listed, got %v", dirty.Path, m.filtered)
+	}
+}
+
+func statusJobs(paths ...string) []statusJob {
+	jobs := make([]statusJob, len(paths))
+	for i, path := range paths {
+		jobs[i] = statusJob{repo: scanner.Repository{Path: path}}
+	}
+	return jobs
+}
+
+func TestStatusQueue_UrgentJobsGoFirst(t *testing.T) {
+	q := &statusQueue{ready: make(chan struct{}, 1)}
+	q.push(statusJobs("/repos/a", "/repos/b"), false)
+	q.push(statusJobs("/repos/visible", "/repos/next"), true)
+	q.push(statusJobs("/repos/c"), false)
+
+	var order []string
+	for {
+		job, ok := q.pop()
+		if !ok {
+			break
+		}
+		order = append(order, job.repo.Path)
+	}
+
+	want := []string{"/repos/visible", "/repos/next", "/repos/a", "/repos/b", "/repos/c"}
+	if !slices.Equal(order, want) {
+		t.Errorf("expected %v, got %v", want, order)
+	}
+}
+
+func TestStatusQueue_DeliversEveryResult(t *testing.T) {
+	quit := make(chan struct{})
+	defer close(quit)
+	q := newStatusQueue(quit)
+
+	dir := t.TempDir()
+	want := make(map[string]bool)
+	var paths []string
+	for i := range 3 * statusWorkers {
+		path := filepath.Join(dir, fmt.Sprintf("repo%d", i))
+		paths = append(paths, path)
+		want[path] = true
+	}
+	q.push(statusJobs(paths...), false)
+
+	for range paths {
+		msg := waitForStatus(q)().(statusMsg)
+		if !want[msg.path] {
+			t.Fatalf("unexpected or repeated status for %s", msg.path)
+		}
+		delete(want, msg.path)
+	}
+}
+
+func TestStatusJob_ReadsAgainOnceStale(t *testing.T) {
+	path := t.TempDir()
+	for _, name := range []string{"HEAD", "index"} {
+		writeTestFile(t, filepath.Join(path, ".git", name))
+	}
+	repo := scanner.Repository{Name: "api", Path: path, VCS: scanner.VCSGit}
+	stamp := vcs.StatusStamp(repo)
+	data := &git.StatusData{CurrentBranch: "main"}
+
+	tests := []struct {
+		name      string
+		previous  statusEntry
+		unchanged bool
+	}{
+		{"fresh", statusEntry{data: data, stamp: stamp, readAt: time.Now(), loaded: true}, true},
+		{"never read", statusEntry{}, false},
+		{"stamp changed", statusEntry{data: data, stamp: stamp.Add(-time.Second), readAt: time.Now(), loaded: true}, false},
+		{"older than statusTTL", statusEntry{data: data, stamp: stamp, readAt: time.Now().Add(-statusTTL - time.Second), loaded: true}, false},
+	}
+
+	for _, tt := range tests {
+		msg := statusJob{repo: repo, previous: tt.previous}.read()
+		if msg.unchanged != tt.unchanged {
+			t.Errorf("%s: expected unchanged to be %v", tt.name, tt.unchanged)
+		}
+	}
+}
+
+func TestSelectionChanged_ReadsSelectedStatusOnce(t *testing.T) {
+	repo := scanner.Repository{Name: "api", Path: "/repos/api"}
+	m := statusModel(t, []scanner.Repository{repo}, nil)
+	m.statusQueue = &statusQueue{ready: make(chan struct{}, 1)} // no workers, to inspect the queue
+
+	m.selectionChanged()
+	m.selectionChanged()
+
+	if len(m.statusQueue.jobs) != 1 || m.statusQueue.jobs[0].repo.Path != repo.Path {
+		t.Fatalf("expected one status read of %s queued, got %+v", repo.Path, m.statusQueue.jobs)
+	}
+	if !m.gitStatusLoading {
+		t.Errorf("expected the status panel to show it is loading")
+	}
+
+	updated, _ := m.Update(statusMsg{path: repo.Path, data: &git.StatusData{CurrentBranch: "main"}})
+	m = updated.(Model)
+	if m.statuses[repo.Path].loading || m.gitStatusLoading || m.gitStatusData == nil {
+		t.Errorf("expected the read status to be shown, got %+v", m.statuses[repo.Path])
+	}
+}
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
@@ -1,23 +1,36 @@
 package ui
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"math"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/sahilm/fuzzy"
 	"github.com/tiagokriok/Git-Fuzzy/internal/config"
 	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+	"github.com/tiagokriok/Git-Fuzzy/internal/history"
+	"github.com/tiagokriok/Git-Fuzzy/internal/metadata"
 	"github.com/tiagokriok/Git-Fuzzy/internal/platform"
 	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
 )
 
 var selectedRepository *scanner.Repository
 
+// selectedQuery is the search that found selectedRepository
+var selectedQuery string
+
 const (
 	maxHeight       = 10
 	boxPadding      = 2
@@ -31,43 +44,128 @@ type gitStatusFetchMsg struct {
 	data     *git.StatusData
 	err      error
 	repoPath string
+	stamp    time.Time // vcs.StatusStamp of the repository before data was read
 }
 
-type debounceTickMsg struct {
-	repoPath string
+type rescanDoneMsg struct {
+	repos       []scanner.Repository
+	diagnostics *scanner.Diagnostics
+	err         error
+}
+
+// reposFoundMsg carries repositories streamed from a scan still in progress
+type reposFoundMsg struct {
+	repos []scanner.Repository
 }
 
+// repoEventMsg carries a change reported by the filesystem watcher
+type repoEventMsg struct {
+	event scanner.Event
+}
+
+// maxFoundBatch bounds how many streamed repositories one message carries, so
+// the list keeps updating while a large tree is scanned
+const maxFoundBatch = 256
+
+// frecencyWeight scales the frecency bonus added to a fuzzy match score. The
+// bonus grows logarithmically, so a favourite repository wins over similar
+// matches without burying a clearly better one.
+const frecencyWeight = 4
+
+// RescanFunc rediscovers repositories in the background while the TUI is
+// already running. It calls found with each repository as soon as it is
+// discovered and returns the complete list once the scan finishes.
+type RescanFunc func(found func(scanner.Repository)) ([]scanner.Repository, *scanner.Diagnostics, error)
+
 type Model struct {
 	repositories     []scanner.Repository
 	filtered         []scanner.Repository
-	searchInput      string
+	search           textinput.Model
+	queries          []string // previous searches, oldest first
+	queryIdx         int      // position in queries while recalling one, len(queries) otherwise
+	draft            string   // what was typed before recalling a query
 	selectedIdx      int
 	scrollOffset     int
 	width            int
 	height           int
 	err              error
 	gitStatusData    *git.StatusData
+	gitStatusPath    string // repository the status panel is for
 	gitStatusScroll  int
 	gitStatusLoading bool
 	gitStatusError   error
 	config           *config.Config
+	rescan           RescanFunc
+	rescanning       bool
+	scanFeed         chan scanner.Repository
+	quit             chan struct{} // closed once the TUI exits, so background work stops delivering results
+	scanCount        int
+	spinner          spinner.Model
+	watchEvents      <-chan scanner.Event
+	enricher         *enricher
+	sortMode         sortMode
+	frecency         map[string]float64     // by path, for repositories opened before
+	remotes          map[string]string      // owner/repo of each repository's remote, by path
+	branches         map[string]string      // checked-out branch of each repository, by path, read with its remote
+	highlights       map[string]highlight   // where each filtered repository matched, by path
+	statuses         map[string]statusEntry // working copy status by path
+	statusQueue      *statusQueue
+	pinned           map[string]bool
+	diagnostics      *scanner.Diagnostics
+	worktree         *worktreeForm
 }
 
-func NewModel(repos []scanner.Repository, cfg *config.Config) Model {
-	return Model{
+func NewModel(repos []scanner.Repository, cfg *config.Config, rescan RescanFunc, events <-chan scanner.Event) Model {
+	m := Model{
 		repositories: repos,
 		filtered:     repos,
 		selectedIdx:  0,
 		config:       cfg,
+		rescan:       rescan,
+		rescanning:   rescan != nil,
+		spinner:      spinner.New(spinner.WithSpinner(spinner.Dot)),
+		watchEvents:  events,
+		enricher:     newEnricher(loadMetadataCache()),
+	}
+	m.search = textinput.New()
+	m.search.Prompt = ""
+	m.search.Focus()
+
+	recent := loadHistory()
+	m.frecency = recent.Scores()
+	m.pinned = make(map[string]bool, len(recent.Pinned))
+	for _, path := range recent.Pinned {
+		m.pinned[path] = true
 	}
+	m.queries = recent.Queries
+	m.queryIdx = len(m.queries)
+	m.remotes = make(map[string]string)
+	m.branches = make(map[string]string)
+	m.statuses = make(map[string]statusEntry)
+	m.quit = make(chan struct{})
+	m.statusQueue = newStatusQueue(m.quit)
+	m.showCachedStatus()
+	if rescan != nil {
+		m.scanFeed = make(chan scanner.Repository, maxFoundBatch)
+	}
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	// Fetch git status for first repository (no debounce delay)
-	if len(m.repositories) > 0 {
-		return m.fetchGitStatusAsync(m.repositories[0].Path)
+	var cmds []tea.Cmd
+
+	// The selected repository's status is read first, along with its neighbours'
+	m.queueStatuses()
+	cmds = append(cmds, m.enrichCmd(), waitForStatus(m.statusQueue), m.loadRemotes(m.repositories), textinput.Blink)
+
+	// Watch events are relative to the rescan, so wait for it to finish
+	if m.rescan != nil {
+		cmds = append(cmds, m.rescanAsync(), waitForRepos(m.scanFeed), m.spinner.Tick)
+	} else if m.watchEvents != nil {
+		cmds = append(cmds, waitForEvent(m.watchEvents))
 	}
-	return nil
+
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -77,32 +175,125 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-	case debounceTickMsg:
-		// Only fetch if still on the same repo
-		if len(m.filtered) > 0 && m.selectedIdx < len(m.filtered) {
-			selected := m.filtered[m.selectedIdx]
-			if selected.Path == msg.repoPath {
-				m.gitStatusLoading = true
-				return m, m.fetchGitStatusAsync(selected.Path)
+		left, _ := m.panelWidths()
+		// Leave room for the search box's padding and the cursor
+		m.search.Width = searchBoxWidthFor(left) - 3
+	case gitStatusFetchMsg:
+		if msg.repoPath == m.gitStatusPath {
+			m.gitStatusLoading = false
+			if msg.err != nil {
+				m.gitStatusError = msg.err
+				m.gitStatusData = nil
+			} else {
+				m.showStatusData(msg.data)
+			}
+		}
+		if msg.err == nil {
+			m.setStatus(msg.repoPath, msg.data, msg.stamp)
+			if parseQuery(m.search.Value()).needsStatus() {
+				return m, m.refilter()
 			}
 		}
 		return m, nil
-	case gitStatusFetchMsg:
-		m.gitStatusLoading = false
+	case reposFoundMsg:
+		// Batches read before the scan finished may arrive after its result
+		if !m.rescanning {
+			return m, nil
+		}
+		m.scanCount += len(msg.repos)
+		return m, tea.Batch(m.addRepositories(msg.repos), waitForRepos(m.scanFeed))
+	case spinner.TickMsg:
+		if !m.rescanning {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case rescanDoneMsg:
+		m.rescanning = false
 		if msg.err != nil {
-			m.gitStatusError = msg.err
-			m.gitStatusData = nil
-		} else {
-			m.gitStatusData = msg.data
-			m.gitStatusError = nil
-			m.gitStatusScroll = 0
+			return m, nil
+		}
+		m.diagnostics = msg.diagnostics
+		cmd := m.setRepositories(msg.repos)
+		if m.watchEvents != nil {
+			cmd = tea.Batch(cmd, waitForEvent(m.watchEvents))
+		}
+		return m, cmd
+	case metadataMsg:
+		// Sorting and filtering by metadata change as it comes in
+		if parseQuery(m.search.Value()).needsMetadata() || m.sortMode.needsMetadata() {
+			return m, m.refilter()
 		}
 		return m, nil
+	case statusMsg:
+		next := waitForStatus(m.statusQueue)
+		if msg.unchanged {
+			entry := m.statuses[msg.path]
+			entry.loading = false
+			m.statuses[msg.path] = entry
+			return m, next
+		}
+		m.setStatus(msg.path, msg.data, msg.stamp)
+		if msg.path == m.gitStatusPath {
+			m.gitStatusLoading = false
+			if msg.err != nil {
+				m.gitStatusError = msg.err
+				m.gitStatusData = nil
+			} else {
+				m.showStatusData(msg.data)
+			}
+		}
+		if parseQuery(m.search.Value()).needsStatus() {
+			return m, tea.Batch(next, m.refilter())
+		}
+		return m, next
+	case remotesMsg:
+		maps.Copy(m.remotes, msg.remotes)
+		maps.Copy(m.branches, msg.branches)
+		if !parseQuery(m.search.Value()).empty() {
+			return m, m.refilter()
+		}
+		return m, nil
+	case repoEventMsg:
+		return m, tea.Batch(m.applyEvent(msg.event), waitForEvent(m.watchEvents))
+	case worktreeCreatedMsg:
+		if msg.err != nil {
+			m.worktree.busy = false
+			m.worktree.err = msg.err
+			return m, nil
+		}
+		selectedRepository = &msg.repo
+		return m, tea.Quit
+	default:
+		// Keep the worktree form's cursor blinking
+		if m.worktree != nil {
+			return m, m.worktree.update(msg)
+		}
+		// Cursor blinks and clipboard pastes
+		return m, m.updateSearch(msg)
 	}
 	return m, nil
 }
 
 func (m Model) View() string {
+	leftPanelWidth, rightPanelWidth := m.panelWidths()
+
+	// Render both panels
+	leftPanel := m.renderLeftPanel(leftPanelWidth)
+	rightPanel := m.renderRightPanel(rightPanelWidth)
+
+	// Join horizontally
+	splitView := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, rightPanel)
+
+	// Add footer
+	footer := m.renderFooter()
+
+	return lipgloss.JoinVertical(lipgloss.Left, splitView, footer)
+}
+
+// panelWidths returns the content widths of the left and right panels
+func (m Model) panelWidths() (left, right int) {
 	// Calculate panel widths (60/40 split)
 	// Each panel has: border (2) + padding (2) = 4 extra chars
 	// We need to account for this "chrome" when calculating content widths
@@ -115,38 +306,31 @@ func (m Model) View() string {
 		totalContentWidth = 40
 	}
 
-	leftPanelWidth := int(float64(totalContentWidth) * 0.55)
-	rightPanelWidth := totalContentWidth - leftPanelWidth
-
-	// Render both panels
-	leftPanel := m.renderLeftPanel(leftPanelWidth)
-	rightPanel := m.renderRightPanel(rightPanelWidth)
-
-	// Join horizontally
-	splitView := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, rightPanel)
-
-	// Add footer
-	footer := m.renderFooter()
+	left = int(float64(totalContentWidth) * 0.55)
+	return left, totalContentWidth - left
+}
 
-	return lipgloss.JoinVertical(lipgloss.Left, splitView, footer)
+// searchBoxWidthFor is the width of the search box in a left panel of width
+func searchBoxWidthFor(width int) int {
+	return min(width-4, searchBoxWidth)
 }
 
 func (m Model) renderLeftPanel(width int) string {
-	searchBoxWidth := min(width-4, 50)
-	searchBoxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("205")).Padding(0, 1).Width(searchBoxWidth).Align(lipgloss.Left)
-
-	selectedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true)
+	searchBoxStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("205")).Padding(0, 1).Width(searchBoxWidthFor(width)).Align(lipgloss.Left)
 
 	availableHeight := max(m.height-footerHeight-4, 3)
 
 	searchLabel := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Search:")
-	searchInput := m.searchInput
-	searchBox := searchBoxStyle.Render(searchInput)
+	searchBox := searchBoxStyle.Render(m.search.View())
 
 	var reposList string
 	if len(m.filtered) == 0 {
 		emptyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Italic(true)
-		reposList = emptyStyle.Render("No repositories found")
+		if m.rescanning && len(m.repositories) == 0 {
+			reposList = emptyStyle.Render("Scanning for repositories…")
+		} else {
+			reposList = emptyStyle.Render("No repositories found")
+		}
 	} else {
 		var lines []string
 
@@ -164,15 +348,7 @@ func (m Model) renderLeftPanel(width int) string {
 			if repoIdx >= len(m.filtered) {
 				break
 			}
-			repo := m.filtered[repoIdx]
-			displayPath := formatRepoPath(repo.Path)
-			line := fmt.Sprintf("%s (%s)", repo.Name, displayPath)
-
-			if repoIdx == m.selectedIdx {
-				lines = append(lines, selectedStyle.Render("▶ "+line))
-			} else {
-				lines = append(lines, "  "+line)
-			}
+			lines = append(lines, m.renderRow(m.filtered[repoIdx], repoIdx == m.selectedIdx))
 		}
 
 		reposList = strings.Join(lines, "\n")
@@ -182,6 +358,9 @@ func (m Model) renderLeftPanel(width int) string {
 	pagination := paginationStyle.Render(paginationInfo)
 
 	content := lipgloss.JoinVertical(lipgloss.Left, searchLabel, searchBox, "", reposList, pagination)
+	if m.worktree != nil {
+		content = m.worktree.view(width)
+	}
 
 	panelStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -193,13 +372,71 @@ func (m Model) renderLeftPanel(width int) string {
 	return panelStyle.Render(content)
 }
 
+// renderRow renders the list entry of repo, with the characters the search
+// matched highlighted
+func (m Model) renderRow(repo scanner.Repository, selected bool) string {
+	base := lipgloss.NewStyle()
+	prefix := "  "
+	if selected {
+		base = base.Foreground(lipgloss.Color("46")).Bold(true)
+		prefix = "▶ "
+	}
+	matched := base.Foreground(lipgloss.Color("205")).Bold(true).Underline(true)
+
+	h := m.highlights[repo.Path]
+	displayPath := formatRepoPath(repo.Path)
+
+	// Path matches are relative to the search path, which the displayed path
+	// ends with
+	var pathOffsets []int
+	if rel := m.relativePath(repo); len(h.path) > 0 && strings.HasSuffix(displayPath, rel) {
+		shift := len(displayPath) - len(rel)
+		for _, offset := range h.path {
+			pathOffsets = append(pathOffsets, offset+shift)
+		}
+	}
+
+	if m.pinned[repo.Path] {
+		prefix += "📌 "
+	}
+	suffix := ")"
+	if tags := repoTags(repo); tags != "" {
+		suffix += " " + tags
+	}
+	if label := m.sortKeyLabel(repo); label != "" {
+		suffix += " · " + label
+	}
+	badges := m.statusBadges(repo)
+	if badges != "" {
+		suffix += " "
+	}
+
+	return base.Render(prefix) +
+		renderHighlighted(repo.Name, h.name, base, matched) +
+		base.Render(" (") +
+		renderHighlighted(displayPath, pathOffsets, base, matched) +
+		base.Render(suffix) +
+		badges
+}
+
 func (m Model) renderRightPanel(width int) string {
 	// Panel title
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("205")).
 		Padding(0, 1)
-	title := titleStyle.Render("📊 Git Status")
+	vcsName := "Git"
+	var details string
+	if len(m.filtered) > 0 {
+		selected := m.filtered[m.selectedIdx]
+		vcsName = vcs.Name(selected)
+		details = m.metadataSummary(selected)
+	}
+	title := titleStyle.Render(fmt.Sprintf("📊 %s Status", vcsName))
+	if details != "" {
+		detailsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Padding(0, 1)
+		title = lipgloss.JoinVertical(lipgloss.Left, title, detailsStyle.Render(details))
+	}
 
 	var content string
 
@@ -211,13 +448,17 @@ func (m Model) renderRightPanel(width int) string {
 			Padding(2, 1)
 		content = emptyStyle.Render("No repository selected")
 
+	} else if m.filtered[m.selectedIdx].Kind == scanner.KindBare {
+		// Bare repositories have no working tree status
+		content = m.renderBareContent()
+
 	} else if m.gitStatusLoading {
 		// Loading state
 		loadingStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240")).
 			Italic(true).
 			Padding(2, 1)
-		content = loadingStyle.Render("Loading git status...")
+		content = loadingStyle.Render("Loading status...")
 
 	} else if m.gitStatusError != nil {
 		// Error state
@@ -286,6 +527,26 @@ func (m Model) renderGitStatusContent(width int) string {
 	)
 }
 
+func (m Model) renderBareContent() string {
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("33")).
+		Bold(true).
+		Padding(0, 1)
+	hintStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Padding(0, 1)
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		headerStyle.Render("🗄  Bare repository"),
+		"",
+		hintStyle.Render("No working tree to open or show status for."),
+		"",
+		hintStyle.Render("Enter: create a worktree"),
+		hintStyle.Render("^B: open remote in browser"),
+	)
+}
+
 func (m Model) renderStatsSection(data *git.StatusData) string {
 	statsStyle := lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color("250"))
 
@@ -428,10 +689,41 @@ func (m Model) renderFilesSection(data *git.StatusData, width int) string {
 
 func (m Model) renderFooter() string {
 	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Align(lipgloss.Center)
-	footer := footerStyle.Render("↑/↓: nav repos | Shift+↑/↓: scroll status | Enter: open | ^O: files | ^T: term | ^B: remote | ^G: refresh | Esc: exit")
+	// Only the core keys, the README lists the others
+	keys := []string{"↑/↓: nav", "Enter: open", "^O: files", "^T: term", "^B: remote", "^G: refresh", "^S: sort", "^P: pin", "Esc: exit"}
+	if m.rescanning {
+		keys = append([]string{fmt.Sprintf("%sscanning… %d found", m.spinner.View(), m.scanCount)}, keys...)
+	}
+	footer := footerStyle.Render(wrapHelp(keys, m.width))
+
+	// Incomplete scans would otherwise just look like missing repositories
+	if summary := m.diagnostics.Summary(); summary != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Align(lipgloss.Center)
+		banner := warningStyle.Render(fmt.Sprintf("⚠ %s · run gitf doctor for details", summary))
+		footer = lipgloss.JoinVertical(lipgloss.Center, banner, footer)
+	}
 	return footer
 }
 
+// wrapHelp joins the keys with separators, starting a new line before a key
+// that would not fit in width.
+func wrapHelp(keys []string, width int) string {
+	var lines []string
+	line := ""
+	for _, key := range keys {
+		switch {
+		case line == "":
+			line = key
+		case width > 0 && lipgloss.Width(line+" | "+key) > width:
+			lines = append(lines, line)
+			line = key
+		default:
+			line += " | " + key
+		}
+	}
+	return strings.Join(append(lines, line), "\n")
+}
+
 func (m *Model) pluralize(count int) string {
 	if count == 1 {
 		return ""
@@ -440,21 +732,71 @@ func (m *Model) pluralize(count int) string {
 }
 
 func (m *Model) updateFiltered() {
-	if m.searchInput == "" {
-		m.filtered = m.repositories
-		return
+	query := parseQuery(m.search.Value())
+
+	candidates := m.repositories
+	if query.language != "" || query.needsStatus() {
+		candidates = nil
+		for _, repo := range m.repositories {
+			if (query.language == "" || m.matchesLanguage(repo, query.language)) && m.matchesStates(repo, query.states) {
+				candidates = append(candidates, repo)
+			}
+		}
+	}
+
+	m.highlights = make(map[string]highlight)
+	if query.empty() {
+		m.filtered = slices.Clone(candidates)
+		m.rankByFrecency(m.filtered, nil)
+	} else {
+		m.filtered = nil
+		var scores []int
+		for _, repo := range candidates {
+			if score, h, ok := m.match(repo, query); ok {
+				m.filtered = append(m.filtered, repo)
+				m.highlights[repo.Path] = h
+				scores = append(scores, score)
+			}
+		}
+		m.rankByFrecency(m.filtered, scores)
 	}
 
-	names := make([]string, len(m.repositories))
-	for i, repo := range m.repositories {
-		names[i] = repo.Name
+	m.sortRepositories(m.filtered)
+
+	// Pinned repositories come first whatever the order
+	slices.SortStableFunc(m.filtered, func(a, b scanner.Repository) int {
+		switch {
+		case m.pinned[a.Path] == m.pinned[b.Path]:
+			return 0
+		case m.pinned[a.Path]:
+			return -1
+		}
+		return 1
+	})
+}
+
+// rankByFrecency orders repos in place by their frecency, added to their
+// match scores while searching. Ties keep their order, i.e. the given order or
+// the order of match quality.
+func (m Model) rankByFrecency(repos []scanner.Repository, matchScores []int) {
+	type ranked struct {
+		repo  scanner.Repository
+		score float64
 	}
 
-	matches := fuzzy.Find(m.searchInput, names)
+	ranks := make([]ranked, len(repos))
+	for i, repo := range repos {
+		ranks[i] = ranked{repo, frecencyWeight * math.Log1p(m.frecency[repo.Path])}
+		if matchScores != nil {
+			ranks[i].score += float64(matchScores[i])
+		}
+	}
 
-	m.filtered = make([]scanner.Repository, len(matches))
-	for i, match := range matches {
-		m.filtered[i] = m.repositories[match.Index]
+	slices.SortStableFunc(ranks, func(a, b ranked) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	for i, r := range ranks {
+		repos[i] = r.repo
 	}
 }
 
@@ -465,36 +807,189 @@ func (m Model) getPaginationInfo() string {
 
 	itemsToShow := min(len(m.filtered), maxHeight)
 
+	var order string
+	if m.sortMode != sortRecent {
+		order = ", by " + m.sortMode.String()
+	}
+
 	if len(m.filtered) <= maxHeight {
-		return fmt.Sprintf("(%d results)", len(m.filtered))
+		return fmt.Sprintf("(%d results%s)", len(m.filtered), order)
 	}
 
-	return fmt.Sprintf("Showing %d of %d", itemsToShow, len(m.filtered))
+	return fmt.Sprintf("Showing %d of %d%s", itemsToShow, len(m.filtered), order)
+}
+
+// selectionChanged fetches what the panels need once the selection or the
+// list changed
+func (m *Model) selectionChanged() tea.Cmd {
+	m.showCachedStatus()
+	m.queueStatuses()
+	return m.enrichCmd()
 }
 
-func (m Model) scheduleGitStatusFetch() tea.Cmd {
+// showCachedStatus shows the cached status of the selected repository right
+// away, or that it is loading if there is none
+func (m *Model) showCachedStatus() {
+	m.gitStatusPath = ""
+	m.gitStatusData = nil
+	m.gitStatusError = nil
+	m.gitStatusScroll = 0
+	m.gitStatusLoading = false
 	if len(m.filtered) == 0 {
-		return nil
+		return
 	}
 
 	selected := m.filtered[m.selectedIdx]
-	return tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
-		return debounceTickMsg{repoPath: selected.Path}
+	m.gitStatusPath = selected.Path
+	m.gitStatusData = m.statuses[selected.Path].data
+	m.gitStatusLoading = m.gitStatusData == nil && selected.Kind != scanner.KindBare
+}
+
+// showStatusData replaces the status shown with a fresh read of the same
+// repository, keeping the scroll position where possible
+func (m *Model) showStatusData(data *git.StatusData) {
+	m.gitStatusData = data
+	m.gitStatusError = nil
+	m.gitStatusScroll = max(min(m.gitStatusScroll, len(data.Files)-1), 0)
+}
+
+func (m Model) rescanAsync() tea.Cmd {
+	rescan := m.rescan
+	feed := m.scanFeed
+	quit := m.quit
+	return func() tea.Msg {
+		repos, diagnostics, err := rescan(func(repo scanner.Repository) {
+			// Nobody reads the feed after the TUI exits; let the scan finish
+			select {
+			case feed <- repo:
+			case <-quit:
+			}
+		})
+		close(feed)
+		return rescanDoneMsg{repos: repos, diagnostics: diagnostics, err: err}
+	}
+}
+
+// waitForRepos waits for the next repositories streamed by a running scan,
+// taking whatever else is already queued along with it. The model asks again
+// after each batch until the feed is closed.
+func waitForRepos(feed <-chan scanner.Repository) tea.Cmd {
+	return func() tea.Msg {
+		repo, ok := <-feed
+		if !ok {
+			return nil
+		}
+
+		repos := []scanner.Repository{repo}
+		for len(repos) < maxFoundBatch {
+			select {
+			case repo, ok := <-feed:
+				if !ok {
+					return reposFoundMsg{repos: repos}
+				}
+				repos = append(repos, repo)
+			default:
+				return reposFoundMsg{repos: repos}
+			}
+		}
+		return reposFoundMsg{repos: repos}
+	}
+}
+
+// waitForEvent waits for the next change reported by the watcher. The model
+// asks again after each event until the watcher is closed.
+func waitForEvent(events <-chan scanner.Event) tea.Cmd {
+	return func() tea.Msg {
+		event, ok := <-events
+		if !ok {
+			return nil
+		}
+		return repoEventMsg{event: event}
+	}
+}
+
+// applyEvent adds, updates or removes the repository an event is about
+func (m *Model) applyEvent(event scanner.Event) tea.Cmd {
+	repos := slices.DeleteFunc(slices.Clone(m.repositories), func(repo scanner.Repository) bool {
+		return repo.Path == event.Repo.Path
 	})
+	if event.Kind == scanner.EventAdded {
+		repos = append(repos, event.Repo)
+	}
+	return m.setRepositories(repos)
 }
 
-func (m Model) fetchGitStatusAsync(repoPath string) tea.Cmd {
+// addRepositories appends streamed repositories that aren't listed yet
+func (m *Model) addRepositories(found []scanner.Repository) tea.Cmd {
+	known := make(map[string]bool, len(m.repositories))
+	for _, repo := range m.repositories {
+		known[repo.Path] = true
+	}
+
+	repos := slices.Clone(m.repositories)
+	for _, repo := range found {
+		if !known[repo.Path] {
+			known[repo.Path] = true
+			repos = append(repos, repo)
+		}
+	}
+
+	if len(repos) == len(m.repositories) {
+		return nil
+	}
+	return m.setRepositories(repos)
+}
+
+// setRepositories replaces the repository list with a fresh scan, keeping the
+// current selection when the selected repository still exists.
+func (m *Model) setRepositories(repos []scanner.Repository) tea.Cmd {
+	m.repositories = repos
+	cmd := m.refilter()
+	m.queueStatuses()
+	return tea.Batch(cmd, m.loadRemotes(repos))
+}
+
+// refilter applies the search and sort order to the repository list again,
+// e.g. once details it filters on come in, keeping the current selection
+// when it is still listed
+func (m *Model) refilter() tea.Cmd {
+	var selectedPath string
+	if len(m.filtered) > 0 {
+		selectedPath = m.filtered[m.selectedIdx].Path
+	}
+
+	m.updateFiltered()
+
+	m.selectedIdx = 0
+	for i, repo := range m.filtered {
+		if repo.Path == selectedPath {
+			m.selectedIdx = i
+			return nil
+		}
+	}
+
+	m.scrollOffset = 0
+	return m.selectionChanged()
+}
+
+func (m Model) fetchGitStatusAsync(repo scanner.Repository) tea.Cmd {
 	return func() tea.Msg {
-		data, err := git.GetDetailedStatus(repoPath)
+		stamp := vcs.StatusStamp(repo)
+		data, err := vcs.GetDetailedStatus(repo)
 		return gitStatusFetchMsg{
 			data:     data,
 			err:      err,
-			repoPath: repoPath,
+			repoPath: repo.Path,
+			stamp:    stamp,
 		}
 	}
 }
 
 func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.worktree != nil {
+		return m.handleWorktreeKey(msg)
+	}
+
 	switch msg.String() {
 	case "ctrl+c", "esc":
 		selectedRepository = nil
@@ -503,43 +998,62 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "ctrl+o": // Open file manager
 		if len(m.filtered) > 0 {
 			selected := m.filtered[m.selectedIdx]
-			m.openFileManager(selected.Path)
+			if m.openFileManager(selected.Path) {
+				return m, recordOpen(selected.Path, history.ActionFileManager, m.search.Value())
+			}
 		}
 		return m, nil
 
 	case "ctrl+t": // Open terminal
 		if len(m.filtered) > 0 {
 			selected := m.filtered[m.selectedIdx]
-			m.openTerminal(selected.Path)
+			if m.openTerminal(selected.Path) {
+				return m, recordOpen(selected.Path, history.ActionTerminal, m.search.Value())
+			}
 		}
 		return m, nil
 
 	case "ctrl+b": // Open in browser
 		if len(m.filtered) > 0 {
 			selected := m.filtered[m.selectedIdx]
-			m.openInBrowser(selected.Path)
+			if m.openInBrowser(selected) {
+				return m, recordOpen(selected.Path, history.ActionBrowser, m.search.Value())
+			}
 		}
 		return m, nil
 
-	case "ctrl+g": // Force refresh git status (bypass debounce)
-		if len(m.filtered) > 0 {
+	case "ctrl+g": // Force refresh git status, whatever is cached
+		if len(m.filtered) > 0 && m.filtered[m.selectedIdx].Kind != scanner.KindBare {
 			selected := m.filtered[m.selectedIdx]
 			m.gitStatusLoading = true
-			return m, m.fetchGitStatusAsync(selected.Path)
+			return m, m.fetchGitStatusAsync(selected)
+		}
+		return m, nil
+
+	case "ctrl+p": // Toggle pin
+		if len(m.filtered) > 0 {
+			return m, m.togglePin(m.filtered[m.selectedIdx])
 		}
 		return m, nil
 
+	case "ctrl+s": // Cycle sort order
+		m.sortMode = m.sortMode.next()
+		m.updateFiltered()
+		m.selectedIdx = 0
+		m.scrollOffset = 0
+		return m, m.selectionChanged()
+
 	case "up", "shift+tab":
 		if m.selectedIdx > 0 {
 			m.selectedIdx--
-			return m, m.scheduleGitStatusFetch()
+			return m, m.selectionChanged()
 		}
 		return m, nil
 
 	case "down", "tab":
 		if m.selectedIdx < len(m.filtered)-1 {
 			m.selectedIdx++
-			return m, m.scheduleGitStatusFetch()
+			return m, m.selectionChanged()
 		}
 		return m, nil
 
@@ -558,28 +1072,99 @@ func (m *Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		if len(m.filtered) > 0 {
 			selected := m.filtered[m.selectedIdx]
+			if selected.Kind == scanner.KindBare {
+				m.worktree = newWorktreeForm(selected)
+				return m, textinput.Blink
+			}
 			selectedRepository = &selected
+			selectedQuery = m.search.Value()
 			return m, tea.Quit
 		}
 		return m, nil
 
-	case "backspace":
-		if len(m.searchInput) > 0 {
-			m.searchInput = m.searchInput[:len(m.searchInput)-1]
-			m.updateFiltered()
-			m.selectedIdx = 0
-			m.scrollOffset = 0
-			return m, m.scheduleGitStatusFetch()
+	case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5": // Toggle an is: filter
+		return m, m.toggleState(repoStates[msg.Runes[0]-'1'])
+
+	case "alt+up": // Recall the previous search
+		if m.queryIdx > 0 {
+			if m.queryIdx == len(m.queries) {
+				m.draft = m.search.Value()
+			}
+			m.queryIdx--
+			return m, m.setQuery(m.queries[m.queryIdx])
+		}
+		return m, nil
+
+	case "alt+down": // Recall the next search, or what was typed
+		if m.queryIdx < len(m.queries) {
+			m.queryIdx++
+			if m.queryIdx == len(m.queries) {
+				return m, m.setQuery(m.draft)
+			}
+			return m, m.setQuery(m.queries[m.queryIdx])
 		}
 		return m, nil
 
 	default:
-		m.searchInput += msg.String()
-		m.updateFiltered()
-		m.selectedIdx = 0
-		m.scrollOffset = 0
-		return m, m.scheduleGitStatusFetch()
+		// Alt combinations the input doesn't bind would insert their letter
+		keys := m.search.KeyMap
+		if msg.Alt && msg.Type == tea.KeyRunes && !key.Matches(msg, keys.WordForward, keys.WordBackward, keys.DeleteWordForward) {
+			return m, nil
+		}
+		return m, m.updateSearch(msg)
+	}
+}
+
+// updateSearch passes msg to the search input, filtering again if it changed
+// the query
+func (m *Model) updateSearch(msg tea.Msg) tea.Cmd {
+	query := m.search.Value()
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	if m.search.Value() == query {
+		return cmd
+	}
+	return tea.Batch(cmd, m.queryChanged())
+}
+
+// setQuery replaces the search query, with the cursor at its end
+func (m *Model) setQuery(query string) tea.Cmd {
+	m.search.SetValue(query)
+	m.search.CursorEnd()
+	return m.queryChanged()
+}
+
+func (m *Model) queryChanged() tea.Cmd {
+	m.updateFiltered()
+	m.selectedIdx = 0
+	m.scrollOffset = 0
+	return m.selectionChanged()
+}
+
+// togglePin pins or unpins repo, keeping it selected as it moves, and saves
+// the change in the background
+func (m *Model) togglePin(repo scanner.Repository) tea.Cmd {
+	pin := !m.pinned[repo.Path]
+	if pin {
+		m.pinned[repo.Path] = true
+	} else {
+		delete(m.pinned, repo.Path)
 	}
+
+	save := func() tea.Msg {
+		recent, err := history.LoadRecent()
+		if err != nil {
+			return nil
+		}
+		if pin {
+			recent.Pin(repo.Path)
+		} else {
+			recent.Unpin(repo.Path)
+		}
+		recent.Save()
+		return nil
+	}
+	return tea.Batch(m.setRepositories(m.repositories), save)
 }
 
 func formatRepoPath(fullPath string) string {
@@ -594,6 +1179,31 @@ func formatRepoPath(fullPath string) string {
 	return fullPath
 }
 
+// repoTags returns the list tags for non-git and non-standard checkouts and
+// for repositories nested inside another one
+func repoTags(repo scanner.Repository) string {
+	var tags []string
+
+	if repo.VCS != scanner.VCSGit {
+		tags = append(tags, fmt.Sprintf("[%s]", repo.VCS))
+	}
+
+	switch repo.Kind {
+	case scanner.KindWorktree:
+		tags = append(tags, "[worktree]")
+	case scanner.KindSubmodule:
+		tags = append(tags, "[submodule]")
+	case scanner.KindBare:
+		tags = append(tags, "[bare]")
+	}
+
+	if repo.Parent != "" {
+		tags = append(tags, fmt.Sprintf("[in %s]", filepath.Base(repo.Parent)))
+	}
+
+	return strings.Join(tags, " ")
+}
+
 // truncatePathLeft truncates a path from the left if it exceeds maxWidth
 // Example: "src/components/dialogs/file.vue" -> "…/dialogs/file.vue"
 func truncatePathLeft(path string, maxWidth int) string {
@@ -618,22 +1228,27 @@ func truncatePathLeft(path string, maxWidth int) string {
 	return "…" + truncated
 }
 
-func (m *Model) openFileManager(repoPath string) {
+// openFileManager opens the file manager at repoPath and reports whether
+// one is configured
+func (m *Model) openFileManager(repoPath string) bool {
 	cmd := m.config.GetFileManager()
 	if cmd == "" {
-		return
+		return false
 	}
 
 	// Fire and forget
 	go func() {
 		exec.Command(cmd, repoPath).Start()
 	}()
+	return true
 }
 
-func (m *Model) openTerminal(repoPath string) {
+// openTerminal opens a terminal in repoPath and reports whether one is
+// configured
+func (m *Model) openTerminal(repoPath string) bool {
 	cmd := m.config.GetTerminal()
 	if cmd == "" {
-		return
+		return false
 	}
 
 	// Fire and forget
@@ -645,37 +1260,102 @@ func (m *Model) openTerminal(repoPath string) {
 			c.Start()
 		}
 	}()
+	return true
 }
 
-func (m *Model) openInBrowser(repoPath string) {
-	remoteURL, err := git.GetRemoteURL(repoPath)
+// openInBrowser opens the web page of repo's remote and reports whether it
+// has one
+func (m *Model) openInBrowser(repo scanner.Repository) bool {
+	remoteURL, err := vcs.GetRemoteURL(repo)
 	if err != nil {
 		// Silently fail - no remote configured
-		return
+		return false
 	}
 
 	httpsURL, err := git.ConvertToHTTPS(remoteURL)
+	if err != nil {
+		return false
+	}
+
+	return platform.OpenInBrowser(httpsURL) == nil
+}
+
+// recordOpen adds an open of the repository at repoPath, and the search
+// query that found it, to the history in the background
+func recordOpen(repoPath string, action history.Action, query string) tea.Cmd {
+	return func() tea.Msg {
+		recent, err := history.LoadRecent()
+		if err != nil {
+			return nil
+		}
+		recent.Add(repoPath, action)
+		recent.AddQuery(strings.TrimSpace(query))
+		recent.Save()
+		return nil
+	}
+}
+
+// saveQuery remembers the search query that found the repository opened
+func saveQuery(query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	recent, err := history.LoadRecent()
 	if err != nil {
 		return
 	}
+	recent.AddQuery(query)
+	recent.Save()
+}
 
-	platform.OpenInBrowser(httpsURL)
+// loadHistory loads the history of opened repositories, starting over if it
+// can't be read
+func loadHistory() *history.Recent {
+	recent, err := history.LoadRecent()
+	if err != nil {
+		return &history.Recent{}
+	}
+	return recent
+}
+
+// loadMetadataCache loads cached repository metadata, starting over if the
+// cache can't be read
+func loadMetadataCache() *metadata.Cache {
+	cache, err := metadata.LoadCache()
+	if err != nil {
+		return metadata.NewCache()
+	}
+	return cache
 }
 
 func GetSelectedRepository() *scanner.Repository {
 	return selectedRepository
 }
 
-func Run(repos []scanner.Repository, cfg *config.Config) (*scanner.Repository, error) {
+// Run starts the TUI with repos, which may be empty. If rescan is non-nil it
+// runs in the background: repositories it finds are added as they come in,
+// and its result replaces the list once it finishes. Problems the rescan
+// reports are shown above the footer. Changes on events, if non-nil, are
+// applied to the list from then on.
+func Run(repos []scanner.Repository, cfg *config.Config, rescan RescanFunc, events <-chan scanner.Event) (*scanner.Repository, error) {
 	selectedRepository = nil
+	selectedQuery = ""
 
-	model := NewModel(repos, cfg)
+	model := NewModel(repos, cfg, rescan, events)
 
 	p := tea.NewProgram(model)
 
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	close(model.quit)
+	model.enricher.cache.Save()
+	if err != nil {
 		return nil, fmt.Errorf("TUI Error: %w", err)
 	}
 
+	if selectedRepository != nil {
+		saveQuery(selectedQuery)
+	}
+
 	return selectedRepository, nil
 }
@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestWrapHelp_FitsWidth(t *testing.T) {
+	keys := []string{"↑/↓: nav", "Enter: open", "^O: files", "^T: term", "Esc: exit"}
+
+	if got, want := wrapHelp(keys, 0), "↑/↓: nav | Enter: open | ^O: files | ^T: term | Esc: exit"; got != want {
+		t.Errorf("expected %q without a width, got %q", want, got)
+	}
+
+	got := wrapHelp(keys, 25)
+	want := "↑/↓: nav | Enter: open\n^O: files | ^T: term\nEsc: exit"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if lipgloss.Width(line) > 25 {
+			t.Errorf("line %q is wider than 25", line)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+	"github.com/tiagokriok/Git-Fuzzy/internal/vcs"
+)
+
+// worktreeForm asks for the path and branch of a new worktree. Bare
+// repositories have no working tree to open in an editor, so Enter on one
+// offers to create a worktree from it instead.
+type worktreeForm struct {
+	repo   scanner.Repository
+	path   textinput.Model
+	branch textinput.Model
+	step   int
+	busy   bool
+	err    error
+}
+
+type worktreeCreatedMsg struct {
+	repo scanner.Repository
+	err  error
+}
+
+func newWorktreeForm(repo scanner.Repository) *worktreeForm {
+	// Read from HEAD rather than running git, as this runs in Update
+	branch := vcs.ReadHeadBranch(repo)
+
+	pathInput := textinput.New()
+	pathInput.Placeholder = "path for the new worktree"
+	pathInput.SetValue(defaultWorktreePath(repo, branch))
+	pathInput.Focus()
+
+	branchInput := textinput.New()
+	branchInput.Placeholder = "branch to check out or create"
+	branchInput.SetValue(branch)
+
+	return &worktreeForm{
+		repo:   repo,
+		path:   pathInput,
+		branch: branchInput,
+	}
+}
+
+// defaultWorktreePath suggests where to create a worktree of the bare
+// repository repo: next to it, named after it without its .git suffix, or
+// after it and branch if that is the repository itself, e.g. "api-main"
+func defaultWorktreePath(repo scanner.Repository, branch string) string {
+	name := strings.TrimSuffix(repo.Name, ".git")
+	path := filepath.Join(filepath.Dir(repo.Path), name)
+	if path != repo.Path {
+		return path
+	}
+
+	suffix := strings.ReplaceAll(branch, "/", "-")
+	if suffix == "" {
+		suffix = "worktree"
+	}
+	return path + "-" + suffix
+}
+
+func (m *Model) handleWorktreeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := m.worktree
+	if form.busy {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		selectedRepository = nil
+		return m, tea.Quit
+
+	case "esc":
+		m.worktree = nil
+		// The search cursor stopped blinking while the form had focus
+		return m, textinput.Blink
+
+	case "tab", "shift+tab":
+		form.step = 1 - form.step
+		if form.step == 0 {
+			form.path.Focus()
+			form.branch.Blur()
+		} else {
+			form.branch.Focus()
+			form.path.Blur()
+		}
+		return m, nil
+
+	case "enter":
+		path := strings.TrimSpace(form.path.Value())
+		branch := strings.TrimSpace(form.branch.Value())
+		if path == "" || branch == "" {
+			form.err = fmt.Errorf("path and branch cannot be empty")
+			return m, nil
+		}
+
+		form.busy = true
+		form.err = nil
+		return m, createWorktreeAsync(form.repo, path, branch)
+	}
+
+	return m, form.update(msg)
+}
+
+// update forwards msg to the focused input
+func (f *worktreeForm) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	if f.step == 0 {
+		f.path, cmd = f.path.Update(msg)
+	} else {
+		f.branch, cmd = f.branch.Update(msg)
+	}
+	return cmd
+}
+
+func createWorktreeAsync(bare scanner.Repository, path, branch string) tea.Cmd {
+	return func() tea.Msg {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return worktreeCreatedMsg{err: err}
+		}
+
+		if err := git.AddWorktree(bare.Path, absPath, branch); err != nil {
+			return worktreeCreatedMsg{err: err}
+		}
+
+		return worktreeCreatedMsg{
+			repo: scanner.Repository{
+				Name: filepath.Base(absPath),
+				Path: absPath,
+				VCS:  scanner.VCSGit,
+				Kind: scanner.KindWorktree,
+			},
+		}
+	}
+}
+
+func (f *worktreeForm) view(width int) string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	inputStyle := lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1).Width(min(width-4, 50))
+
+	title := headerStyle.Render(fmt.Sprintf("🌱 New worktree from %s", f.repo.Name))
+
+	lines := []string{
+		title,
+		"",
+		labelStyle.Render("Path:"),
+		inputStyle.Render(f.path.View()),
+		labelStyle.Render("Branch:"),
+		inputStyle.Render(f.branch.View()),
+		"",
+	}
+
+	switch {
+	case f.busy:
+		lines = append(lines, labelStyle.Italic(true).Render("Creating worktree..."))
+	case f.err != nil:
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Width(width).Render("⚠ "+f.err.Error()))
+	default:
+		lines = append(lines, labelStyle.Render("Enter: create & open | Tab: switch field | Esc: cancel"))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
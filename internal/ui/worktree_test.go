@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func TestDefaultWorktreePath(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "src")
+
+	tests := []struct {
+		repo   scanner.Repository
+		branch string
+		want   string
+	}{
+		{scanner.Repository{Name: "api.git", Path: filepath.Join(src, "api.git")}, "main", filepath.Join(src, "api")},
+		{scanner.Repository{Name: "api", Path: filepath.Join(src, "api")}, "main", filepath.Join(src, "api-main")},
+		{scanner.Repository{Name: "api", Path: filepath.Join(src, "api")}, "feature/login", filepath.Join(src, "api-feature-login")},
+		{scanner.Repository{Name: "api", Path: filepath.Join(src, "api")}, "", filepath.Join(src, "api-worktree")},
+	}
+
+	for _, tt := range tests {
+		if got := defaultWorktreePath(tt.repo, tt.branch); got != tt.want {
+			t.Errorf("%s on %q: expected %s, got %s", tt.repo.Path, tt.branch, tt.want, got)
+		}
+	}
+}
+
+func TestNewWorktreeForm_ReadsBranchFromHead(t *testing.T) {
+	bare := filepath.Join(t.TempDir(), "api")
+	if err := os.MkdirAll(bare, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", bare, err)
+	}
+	if err := os.WriteFile(filepath.Join(bare, "HEAD"), []byte("ref: refs/heads/trunk\n"), 0644); err != nil {
+		t.Fatalf("failed to write HEAD: %v", err)
+	}
+
+	form := newWorktreeForm(scanner.Repository{Name: "api", Path: bare, VCS: scanner.VCSGit, Kind: scanner.KindBare})
+	if got := form.branch.Value(); got != "trunk" {
+		t.Errorf("expected branch trunk, got %q", got)
+	}
+	if got := form.path.Value(); got != bare+"-trunk" {
+		t.Errorf("expected path %s-trunk, got %s", bare, got)
+	}
+}
@@ -0,0 +1,98 @@
+package vcs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+)
+
+// fossilCodes maps the labels of fossil changes to git status codes.
+// Anything else, like UPDATED_BY_MERGE, counts as modified.
+var fossilCodes = map[string]string{
+	"EDITED":  "M",
+	"ADDED":   "A",
+	"DELETED": "D",
+	"MISSING": "D",
+	"RENAMED": "R",
+}
+
+// fossilStatus describes the checkout of a fossil repository, with files
+// not under version control reported as untracked
+func fossilStatus(repoPath string) (*git.StatusData, error) {
+	data := &git.StatusData{
+		Files: make([]git.FileStatus, 0),
+	}
+
+	branch, err := fossilBranch(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	data.CurrentBranch = branch
+
+	// Lines look like "EDITED     src/main.c"
+	changes, err := run(repoPath, "fossil", "changes")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines(changes) {
+		label, filename, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		code, ok := fossilCodes[label]
+		if !ok {
+			code = "M"
+		}
+		addFile(data, code, strings.TrimSpace(filename))
+	}
+
+	// Untracked files are only listed by extras
+	if extras, err := run(repoPath, "fossil", "extras"); err == nil {
+		for _, filename := range lines(extras) {
+			addFile(data, "??", strings.TrimSpace(filename))
+		}
+	}
+
+	return data, nil
+}
+
+func fossilBranch(repoPath string) (string, error) {
+	branch, err := run(repoPath, "fossil", "branch", "current")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(branch), nil
+}
+
+// fossilLastCommit reads the check-in time from fossil info, whose checkout
+// line looks like "checkout: <hash> 2024-05-01 12:34:56 UTC"
+func fossilLastCommit(repoPath string) (time.Time, error) {
+	output, err := run(repoPath, "fossil", "info")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, line := range lines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "checkout:" {
+			continue
+		}
+		return time.Parse(time.DateTime, fields[2]+" "+fields[3])
+	}
+	return time.Time{}, fmt.Errorf("no checkout information")
+}
+
+func fossilRemoteURL(repoPath string) (string, error) {
+	url, err := run(repoPath, "fossil", "remote")
+	if err != nil {
+		return "", errNoRemote
+	}
+
+	url = strings.TrimSpace(url)
+	if url == "" || url == "off" {
+		return "", errNoRemote
+	}
+	return url, nil
+}
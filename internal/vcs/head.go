@@ -0,0 +1,40 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+// ReadHeadBranch returns the branch the working copy of repo is on like
+// GetHeadBranch, but reads it straight from the VCS's files instead of
+// running it, so it is cheap enough to call for every repository. It returns
+// "" when no branch is checked out or the files can't be read, and always
+// for Jujutsu and Fossil, which keep it in a database.
+func ReadHeadBranch(repo scanner.Repository) string {
+	switch repo.VCS {
+	case scanner.VCSGit:
+		dir := repo.Path
+		if repo.Kind != scanner.KindBare {
+			dir = scanner.GitDir(repo.Path)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "HEAD"))
+		if err != nil {
+			return ""
+		}
+		branch, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: refs/heads/")
+		if !ok {
+			return "" // detached
+		}
+		return branch
+	case scanner.VCSMercurial:
+		data, err := os.ReadFile(filepath.Join(repo.Path, ".hg", "branch"))
+		if os.IsNotExist(err) {
+			return "default" // hg only writes the file once another branch is used
+		}
+		return strings.TrimSpace(string(data))
+	}
+	return ""
+}
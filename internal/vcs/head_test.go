@@ -0,0 +1,46 @@
+package vcs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func TestReadHeadBranch(t *testing.T) {
+	dir := t.TempDir()
+
+	main := filepath.Join(dir, "api")
+	writeFile(t, filepath.Join(main, ".git", "HEAD"), "ref: refs/heads/main\n")
+
+	worktree := filepath.Join(dir, "api-feature")
+	gitDir := filepath.Join(main, ".git", "worktrees", "api-feature")
+	writeFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/feature/login\n")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+gitDir+"\n")
+
+	detached := filepath.Join(dir, "detached")
+	writeFile(t, filepath.Join(detached, ".git", "HEAD"), "3f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a39\n")
+
+	bare := filepath.Join(dir, "bare.git")
+	writeFile(t, filepath.Join(bare, "HEAD"), "ref: refs/heads/trunk\n")
+
+	hg := filepath.Join(dir, "hg")
+	writeFile(t, filepath.Join(hg, ".hg", "requires"), "")
+
+	tests := []struct {
+		repo scanner.Repository
+		want string
+	}{
+		{scanner.Repository{Path: main, VCS: scanner.VCSGit}, "main"},
+		{scanner.Repository{Path: worktree, VCS: scanner.VCSGit, Kind: scanner.KindWorktree}, "feature/login"},
+		{scanner.Repository{Path: detached, VCS: scanner.VCSGit}, ""},
+		{scanner.Repository{Path: bare, VCS: scanner.VCSGit, Kind: scanner.KindBare}, "trunk"},
+		{scanner.Repository{Path: hg, VCS: scanner.VCSMercurial}, "default"},
+	}
+
+	for _, tt := range tests {
+		if got := ReadHeadBranch(tt.repo); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.repo.Path, tt.want, got)
+		}
+	}
+}
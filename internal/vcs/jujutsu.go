@@ -0,0 +1,115 @@
+package vcs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+)
+
+// jujutsuStatus describes the working-copy commit. jj tracks new files
+// automatically, so nothing is ever untracked, and there is no stash.
+func jujutsuStatus(repoPath string) (*git.StatusData, error) {
+	data := &git.StatusData{
+		Files: make([]git.FileStatus, 0),
+	}
+
+	head, err := jujutsuHead(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	data.CurrentBranch = head
+
+	// Lines look like "M src/main.rs" or "R {old => new}"
+	summary, err := run(repoPath, "jj", "diff", "--summary")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines(summary) {
+		status, filename, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		addFile(data, status, strings.TrimSpace(filename))
+	}
+
+	return data, nil
+}
+
+// jujutsuHead describes the working-copy commit by the first bookmark
+// pointing at it, or else by its change ID
+func jujutsuHead(repoPath string) (string, error) {
+	head, err := run(repoPath, "jj", "log", "--no-graph", "-r", "@", "-T", `change_id.shortest(8) ++ " " ++ bookmarks`)
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(head)
+	switch {
+	case len(fields) > 1:
+		return strings.TrimSuffix(fields[1], "*"), nil
+	case len(fields) == 1:
+		return "@ " + fields[0], nil
+	}
+	return "", fmt.Errorf("unexpected jj log output %q", head)
+}
+
+// jujutsuBranch returns the first bookmark on the working-copy commit
+func jujutsuBranch(repoPath string) (string, error) {
+	head, err := jujutsuHead(repoPath)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(head, "@ ") {
+		return "", fmt.Errorf("no bookmark on the working copy")
+	}
+	return head, nil
+}
+
+// jujutsuLastCommit returns the committer time of the working copy's parent,
+// since the working-copy commit itself changes with every snapshot
+func jujutsuLastCommit(repoPath string) (time.Time, error) {
+	output, err := run(repoPath, "jj", "log", "--no-graph", "-r", "@-", "-T", `committer.timestamp().utc().format("%s") ++ "\n"`)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// A merge has several parents; any of them will do
+	for _, line := range lines(output) {
+		if seconds, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64); err == nil {
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no commits yet")
+}
+
+// jujutsuRemoteURL returns the URL of the origin git remote, or of the
+// first one if there is no origin
+func jujutsuRemoteURL(repoPath string) (string, error) {
+	output, err := run(repoPath, "jj", "git", "remote", "list")
+	if err != nil {
+		return "", err
+	}
+
+	var first string
+	for _, line := range lines(output) {
+		name, url, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		url = strings.TrimSpace(url)
+		if name == "origin" {
+			return url, nil
+		}
+		if first == "" {
+			first = url
+		}
+	}
+
+	if first == "" {
+		return "", errNoRemote
+	}
+	return first, nil
+}
@@ -0,0 +1,85 @@
+package vcs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+)
+
+// mercurialCodes maps hg status codes to git's
+var mercurialCodes = map[string]string{
+	"M": "M",
+	"A": "A",
+	"R": "D", // removed
+	"!": "D", // missing
+	"?": "??",
+}
+
+// mercurialStatus describes the working directory of an hg checkout.
+// Incoming and outgoing changes would need the network, so ahead and behind
+// counts are left out.
+func mercurialStatus(repoPath string) (*git.StatusData, error) {
+	data := &git.StatusData{
+		Files: make([]git.FileStatus, 0),
+	}
+
+	branch, err := mercurialBranch(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	data.CurrentBranch = branch
+
+	// Lines look like "M path/to/file"
+	status, err := run(repoPath, "hg", "status")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines(status) {
+		if len(line) < 3 {
+			continue
+		}
+		if code, ok := mercurialCodes[line[:1]]; ok {
+			addFile(data, code, line[2:])
+		}
+	}
+
+	return data, nil
+}
+
+func mercurialBranch(repoPath string) (string, error) {
+	branch, err := run(repoPath, "hg", "branch")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(branch), nil
+}
+
+// mercurialLastCommit returns the date of the working directory's parent
+func mercurialLastCommit(repoPath string) (time.Time, error) {
+	// hgdate is "<unix seconds> <offset>"
+	output, err := run(repoPath, "hg", "log", "-l", "1", "-r", ".", "-T", "{date|hgdate}")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return time.Time{}, fmt.Errorf("no commits yet")
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected hg date %q", output)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+func mercurialRemoteURL(repoPath string) (string, error) {
+	url, err := run(repoPath, "hg", "paths", "default")
+	if err != nil {
+		return "", errNoRemote
+	}
+	return strings.TrimSpace(url), nil
+}
@@ -0,0 +1,106 @@
+package vcs
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+// ReadRemoteURL returns the URL repo pulls from by default like GetRemoteURL,
+// but reads it straight from the configuration files instead of running the
+// VCS, so it is cheap enough to call for every repository. It returns "" when
+// there is no remote or the configuration can't be read, and always for
+// Fossil, which keeps it in a database.
+func ReadRemoteURL(repo scanner.Repository) string {
+	switch repo.VCS {
+	case scanner.VCSGit:
+		if repo.Kind == scanner.KindBare {
+			return readGitRemote(filepath.Join(repo.Path, "config"))
+		}
+		return readGitRemote(filepath.Join(gitCommonDir(repo.Path), "config"))
+	case scanner.VCSJujutsu:
+		// Colocated workspaces share the git configuration
+		if url := readGitRemote(filepath.Join(gitCommonDir(repo.Path), "config")); url != "" {
+			return url
+		}
+		return readGitRemote(filepath.Join(repo.Path, ".jj", "repo", "store", "git", "config"))
+	case scanner.VCSMercurial:
+		return readINIValue(filepath.Join(repo.Path, ".hg", "hgrc"), "paths", "default")
+	}
+	return ""
+}
+
+func readGitRemote(configPath string) string {
+	return readINIValue(configPath, `remote "origin"`, "url")
+}
+
+// gitCommonDir resolves the git directory holding the configuration of a
+// checkout, following the .git file of worktrees and submodules and the
+// commondir of linked worktrees
+func gitCommonDir(path string) string {
+	dir := scanner.GitDir(path)
+	if common, err := os.ReadFile(filepath.Join(dir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(dir, commonDir)
+		}
+		return commonDir
+	}
+	return dir
+}
+
+// readINIValue returns the value of key in section of a git config or hgrc
+// style file. Only what remotes need is supported: no includes, no escapes.
+func readINIValue(path, section, key string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	inSection := false
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			name := strings.TrimSpace(strings.Trim(line, "[]"))
+			inSection = name == section
+			continue
+		}
+
+		if !inSection {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(name), key) {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// OwnerRepo shortens a remote URL to its last two path elements, e.g.
+// "acme/api" for git@github.com:acme/api.git or https://github.com/acme/api
+func OwnerRepo(url string) string {
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+
+	// Drop the scheme, or the host of scp-like URLs such as host:owner/repo
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = rest
+	} else if _, rest, ok := strings.Cut(url, ":"); ok {
+		url = rest
+	}
+
+	parts := strings.Split(filepath.ToSlash(url), "/")
+	if len(parts) > 2 {
+		parts = parts[len(parts)-2:]
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,55 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func TestOwnerRepo(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:acme/api.git":          "acme/api",
+		"https://github.com/acme/api":          "acme/api",
+		"https://gitlab.com/group/sub/api.git": "sub/api",
+		"ssh://git@host:2222/acme/api.git":     "acme/api",
+		"/srv/git/api.git":                     "git/api",
+	}
+
+	for url, want := range tests {
+		if got := OwnerRepo(url); got != want {
+			t.Errorf("OwnerRepo(%q): expected %q, got %q", url, want, got)
+		}
+	}
+}
+
+func TestReadRemoteURL_Worktree(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "api")
+	worktree := filepath.Join(dir, "api-feature")
+
+	config := "[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = git@github.com:other/api.git\n[remote \"origin\"]\n\turl = git@github.com:acme/api.git\n"
+	writeFile(t, filepath.Join(main, ".git", "config"), config)
+
+	gitDir := filepath.Join(main, ".git", "worktrees", "api-feature")
+	writeFile(t, filepath.Join(gitDir, "commondir"), "../..\n")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+gitDir+"\n")
+
+	for _, path := range []string{main, worktree} {
+		got := ReadRemoteURL(scanner.Repository{Path: path, VCS: scanner.VCSGit})
+		if got != "git@github.com:acme/api.git" {
+			t.Errorf("%s: expected the origin URL, got %q", path, got)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
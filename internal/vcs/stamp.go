@@ -0,0 +1,45 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+// StatusStamp returns when the files the VCS of repo rewrites as its status
+// changes were last modified, e.g. git's index and HEAD. A status read while
+// the stamp was the same is most likely still current; only edits to working
+// files go unnoticed, since nothing records them. It returns the zero time
+// when none of the files exist.
+func StatusStamp(repo scanner.Repository) time.Time {
+	var files []string
+	switch repo.VCS {
+	case scanner.VCSGit:
+		dir := scanner.GitDir(repo.Path)
+		files = []string{
+			filepath.Join(dir, "index"),
+			filepath.Join(dir, "HEAD"),
+			filepath.Join(dir, "logs", "HEAD"), // commits, which leave HEAD itself alone
+			filepath.Join(dir, "FETCH_HEAD"),   // upstream changes, for ahead and behind
+		}
+	case scanner.VCSJujutsu:
+		files = []string{
+			filepath.Join(repo.Path, ".jj", "working_copy", "checkout"),
+			filepath.Join(repo.Path, ".jj", "repo", "op_heads", "heads"),
+		}
+	case scanner.VCSMercurial:
+		files = []string{filepath.Join(repo.Path, ".hg", "dirstate")}
+	case scanner.VCSFossil:
+		files = []string{filepath.Join(repo.Path, ".fslckout"), filepath.Join(repo.Path, "_FOSSIL_")}
+	}
+
+	var latest time.Time
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
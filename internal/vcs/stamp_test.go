@@ -0,0 +1,41 @@
+package vcs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+func TestStatusStamp_Worktree(t *testing.T) {
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, "api", ".git", "worktrees", "api-feature")
+	worktree := filepath.Join(dir, "api-feature")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+gitDir+"\n")
+	writeFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/feature\n")
+	writeFile(t, filepath.Join(gitDir, "index"), "")
+
+	repo := scanner.Repository{Path: worktree, VCS: scanner.VCSGit, Kind: scanner.KindWorktree}
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, name := range []string{"HEAD", "index"} {
+		if err := os.Chtimes(filepath.Join(gitDir, name), base, base); err != nil {
+			t.Fatalf("failed to set mtime: %v", err)
+		}
+	}
+
+	stamp := StatusStamp(repo)
+	if !stamp.Equal(base) {
+		t.Fatalf("expected stamp %v, got %v", base, stamp)
+	}
+
+	// Staging a change rewrites the index of the worktree
+	later := base.Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(gitDir, "index"), later, later); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+	if stamp := StatusStamp(repo); !stamp.Equal(later) {
+		t.Errorf("expected stamp %v after staging, got %v", later, stamp)
+	}
+}
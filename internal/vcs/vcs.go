@@ -0,0 +1,164 @@
+// Package vcs gives the TUI one way to query any supported checkout. Git
+// repositories go through the git package; other version control systems
+// report their status in the same shape, minus what they have no notion of.
+package vcs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/tiagokriok/Git-Fuzzy/internal/git"
+	"github.com/tiagokriok/Git-Fuzzy/internal/scanner"
+)
+
+var errNoRemote = errors.New("no remote configured")
+
+// provider implements the per-VCS queries
+type provider struct {
+	status     func(repoPath string) (*git.StatusData, error)
+	remoteURL  func(repoPath string) (string, error)
+	branch     func(repoPath string) (string, error)
+	lastCommit func(repoPath string) (time.Time, error)
+}
+
+var providers = map[scanner.VCS]provider{
+	scanner.VCSGit: {
+		status:     git.GetDetailedStatus,
+		remoteURL:  git.GetRemoteURL,
+		branch:     git.GetHeadBranch,
+		lastCommit: git.GetLastCommitTime,
+	},
+	scanner.VCSJujutsu: {
+		status:     jujutsuStatus,
+		remoteURL:  jujutsuRemoteURL,
+		branch:     jujutsuBranch,
+		lastCommit: jujutsuLastCommit,
+	},
+	scanner.VCSMercurial: {
+		status:     mercurialStatus,
+		remoteURL:  mercurialRemoteURL,
+		branch:     mercurialBranch,
+		lastCommit: mercurialLastCommit,
+	},
+	scanner.VCSFossil: {
+		status:     fossilStatus,
+		remoteURL:  fossilRemoteURL,
+		branch:     fossilBranch,
+		lastCommit: fossilLastCommit,
+	},
+}
+
+func providerFor(repo scanner.Repository) (provider, error) {
+	p, ok := providers[repo.VCS]
+	if !ok {
+		return provider{}, fmt.Errorf("unsupported version control system %q", repo.VCS)
+	}
+	return p, nil
+}
+
+// GetDetailedStatus returns the working copy status of repo
+func GetDetailedStatus(repo scanner.Repository) (*git.StatusData, error) {
+	p, err := providerFor(repo)
+	if err != nil {
+		return nil, err
+	}
+	return p.status(repo.Path)
+}
+
+// GetRemoteURL returns the URL repo pulls from by default
+func GetRemoteURL(repo scanner.Repository) (string, error) {
+	p, err := providerFor(repo)
+	if err != nil {
+		return "", err
+	}
+	return p.remoteURL(repo.Path)
+}
+
+// GetHeadBranch returns the branch the working copy of repo is on. Without
+// a checked-out branch it returns an error.
+func GetHeadBranch(repo scanner.Repository) (string, error) {
+	p, err := providerFor(repo)
+	if err != nil {
+		return "", err
+	}
+	return p.branch(repo.Path)
+}
+
+// GetLastCommitTime returns when the commit the working copy of repo is
+// based on was made
+func GetLastCommitTime(repo scanner.Repository) (time.Time, error) {
+	p, err := providerFor(repo)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return p.lastCommit(repo.Path)
+}
+
+// Name returns how the VCS of repo is usually written
+func Name(repo scanner.Repository) string {
+	switch repo.VCS {
+	case scanner.VCSJujutsu:
+		return "jj"
+	case scanner.VCSMercurial:
+		return "Mercurial"
+	case scanner.VCSFossil:
+		return "Fossil"
+	default:
+		return "Git"
+	}
+}
+
+// run executes tool in dir and returns its output, with a readable error
+// when the tool isn't installed
+func run(dir, tool string, args ...string) (string, error) {
+	cmd := exec.Command(tool, args...)
+	cmd.Dir = dir
+
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s is not installed", tool)
+		}
+		return "", fmt.Errorf("%s %s failed: %s", tool, args[0], strings.TrimSpace(stderr.String()))
+	}
+	return out.String(), nil
+}
+
+// lines splits command output into non-empty lines
+func lines(output string) []string {
+	var result []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			result = append(result, strings.TrimRight(line, "\r"))
+		}
+	}
+	return result
+}
+
+// addFile records a changed file under its git-style status code
+func addFile(data *git.StatusData, status, filename string) {
+	switch status {
+	case "M":
+		data.ModifiedCount++
+	case "A":
+		data.AddedCount++
+	case "D":
+		data.DeletedCount++
+	case "R":
+		data.RenamedCount++
+	case "C":
+		data.CopiedCount++
+	case "??":
+		data.UntrackedCount++
+	}
+
+	data.Files = append(data.Files, git.FileStatus{Status: status, Filename: filename})
+}